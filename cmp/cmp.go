@@ -1,255 +1,202 @@
 package cmp
 
-import "errors"
+import (
+	"encoding/json"
+	"reflect"
+)
 
+// Nothing represents the absence of a value, e.g. the result of a singular query that selects no node. It is distinct
+// from the JSON null value, which is represented by nil.
+type Nothing struct{}
+
+// Compare compares the two values with the given operator, as defined in RFC 9535 §2.3.5.2.2. A nil error means that
+// the comparison is true.
 func Compare(a, b any, op string) error {
 	switch op {
 	case "==":
-		return eq(a, b)
+		if !Equal(a, b) {
+			return NewNotEqualError(a, b)
+		}
+		return nil
 	case "!=":
-		if err := eq(a, b); err == nil {
+		if Equal(a, b) {
 			return NewEqualError(a, b)
 		}
 		return nil
+	case "<":
+		return lt(a, b)
+	case ">":
+		if err := lt(b, a); err != nil {
+			if _, ok := err.(*notLesserThanError); ok {
+				return NewNotGreaterThanError(a, b)
+			}
+			return err
+		}
+		return nil
 	case "<=":
-		err := gt(a, b)
-		if err == nil {
+		if lt(a, b) != nil && !Equal(a, b) {
 			return NewNotLesserThanOrEqualError(a, b)
 		}
-		var typeNotSupported *TypeNotSupportedError
-		if errors.As(err, &typeNotSupported) {
-			return eq(a, b)
-		}
-		var notGreaterThan *NotGreaterThanError
-		if !errors.As(err, &notGreaterThan) {
-			return err
-		}
 		return nil
 	case ">=":
-		err := lt(a, b)
-		if err == nil {
+		if lt(b, a) != nil && !Equal(a, b) {
 			return NewNotGreaterThanOrEqualError(a, b)
 		}
-		var typeNotSupported *TypeNotSupportedError
-		if errors.As(err, &typeNotSupported) {
-			return eq(a, b)
-		}
-		var notLesserThan *notLesserThanError
-		if !errors.As(err, &notLesserThan) {
-			return err
-		}
 		return nil
-	case "<":
-		return lt(a, b)
-	case ">":
-		return gt(a, b)
 	default:
 		return NewOperatorNotSupportedError(op)
 	}
 }
 
-func eq(a, b any) error {
-	if a == nil && b == nil {
-		return nil
+// Equal reports whether the two values are equal.
+//   - Nothing is only equal to Nothing.
+//   - Numbers are equal if they have the same numeric value, regardless of their Go type.
+//   - Strings, booleans and null are equal if they are the same value.
+//   - Arrays are equal if they have the same length and their elements are equal in order.
+//   - Objects are equal if they have the same member names, and the values of members with the same name are equal.
+func Equal(a, b any) bool {
+	if _, ok := a.(Nothing); ok {
+		_, ok := b.(Nothing)
+		return ok
+	}
+	if _, ok := b.(Nothing); ok {
+		return false
 	}
 	if a == nil || b == nil {
-		return NewNotEqualError(a, b)
+		return a == nil && b == nil
 	}
 
-	// Support for int to int64 conversion.
-	if i, ok := a.(int); ok {
-		a = int64(i)
-	}
-	if i, ok := b.(int); ok {
-		b = int64(i)
+	if x, ok := Numeric(a); ok {
+		y, ok := Numeric(b)
+		if !ok {
+			return false
+		}
+		return compareNumbers(x, y) == 0
 	}
 
 	switch a := a.(type) {
-	case int64:
-		i, ok := b.(int64)
-		if !ok {
-			f, ok := b.(float64)
-			if !ok {
-				return NewTypeMismatchError(a, b)
-			}
-			if float64(a) != f {
-				return NewNotEqualError(a, b)
-			}
-			return nil
-		}
-		if a != i {
-			return NewNotEqualError(a, b)
-		}
-		return nil
-	case float64:
-		f, ok := b.(float64)
-		if !ok {
-			i, ok := b.(int64)
-			if !ok {
-				return NewTypeMismatchError(a, b)
-			}
-			f = float64(i)
-		}
-		if a != f {
-			return NewNotEqualError(a, b)
-		}
-		return nil
 	case string:
-		s, ok := b.(string)
-		if !ok {
-			return NewTypeMismatchError(a, b)
-		}
-		if a != s {
-			return NewNotEqualError(a, b)
-		}
-		return nil
+		b, ok := b.(string)
+		return ok && a == b
 	case bool:
 		b, ok := b.(bool)
-		if !ok {
-			return NewTypeMismatchError(a, b)
-		}
-		if a != b {
-			return NewNotEqualError(a, b)
-		}
-		return nil
+		return ok && a == b
 	case []any:
 		b, ok := b.([]any)
-		if !ok {
-			return NewTypeMismatchError(a, b)
-		}
-		if len(a) != len(b) {
-			return NewNotEqualError(a, b)
+		if !ok || len(a) != len(b) {
+			return false
 		}
 		for i := range a {
-			if err := eq(a[i], b[i]); err != nil {
-				return err
+			if !Equal(a[i], b[i]) {
+				return false
 			}
 		}
-		return nil
+		return true
 	case map[string]any:
 		b, ok := b.(map[string]any)
-		if !ok {
-			return NewTypeMismatchError(a, b)
-		}
-		if len(a) != len(b) {
-			return NewNotEqualError(a, b)
+		if !ok || len(a) != len(b) {
+			return false
 		}
 		for key, value := range a {
-			if err := eq(value, b[key]); err != nil {
-				return err
+			other, ok := b[key]
+			if !ok || !Equal(value, other) {
+				return false
 			}
 		}
-		return nil
+		return true
 	default:
-		return NewTypeNotSupportedError(a)
+		return false
 	}
 }
 
-func gt(a, b any) error {
-	if a == nil && b == nil {
-		return NewNotGreaterThanError(a, b)
-	}
-	if a == nil || b == nil {
-		return NewTypeMismatchError(a, b)
-	}
-
-	// Support for int to int64 conversion.
-	if i, ok := a.(int); ok {
-		a = int64(i)
-	}
-	if i, ok := b.(int); ok {
-		b = int64(i)
-	}
+// Less reports whether a is lesser than b. Only numbers and strings can be ordered, all other values (including
+// Nothing) are neither lesser nor greater than any other value.
+func Less(a, b any) bool {
+	return lt(a, b) == nil
+}
 
-	switch a := a.(type) {
+// Numeric returns the numeric value of v, which is either an int64 or a float64. The second return value is false if v
+// is not a number. All Go integer and floating point types, as well as json.Number, are supported.
+func Numeric(v any) (any, bool) {
+	switch v := v.(type) {
 	case int64:
-		i, ok := b.(int64)
-		if !ok {
-			f, ok := b.(float64)
-			if !ok || float64(a) <= f {
-				return NewTypeMismatchError(a, b)
-			}
-			return nil
-		}
-		if a <= i {
-			return NewNotGreaterThanError(a, b)
-		}
-		return nil
+		return v, true
 	case float64:
-		f, ok := b.(float64)
-		if !ok {
-			i, ok := b.(int64)
-			if !ok {
-				return NewTypeMismatchError(a, b)
-			}
-			f = float64(i)
-		}
-		if a <= f {
-			return NewNotGreaterThanError(a, b)
-		}
-		return nil
-	case string:
-		s, ok := b.(string)
-		if !ok {
-			return NewTypeMismatchError(a, b)
-		}
-		if a <= s {
-			return NewNotGreaterThanError(a, b)
-		}
-		return nil
+		return v, true
+	case int:
+		return int64(v), true
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i, true
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return nil, false
+		}
+		return f, true
+	case nil, bool, string:
+		return nil, false
+	}
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if u := rv.Uint(); u <= 1<<63-1 {
+			return int64(u), true
+		}
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
 	default:
-		return NewTypeNotSupportedError(a)
+		return nil, false
 	}
 }
 
-func lt(a, b any) error {
-	if a == nil && b == nil {
-		return NewNotLesserThanError(a, b)
-	}
-	if a == nil || b == nil {
-		return NewTypeMismatchError(a, b)
-	}
-
-	// Support for int to int64 conversion.
-	if i, ok := a.(int); ok {
-		a = int64(i)
+// compareNumbers returns -1, 0 or 1 if x is lesser than, equal to or greater than y. Both values must be results of
+// Numeric. Integers are compared exactly, mixed comparisons are done on floating point values.
+func compareNumbers(x, y any) int {
+	if i, ok := x.(int64); ok {
+		if j, ok := y.(int64); ok {
+			switch {
+			case i < j:
+				return -1
+			case i > j:
+				return 1
+			default:
+				return 0
+			}
+		}
 	}
-	if i, ok := b.(int); ok {
-		b = int64(i)
+	f, g := toFloat(x), toFloat(y)
+	switch {
+	case f < g:
+		return -1
+	case f > g:
+		return 1
+	default:
+		return 0
 	}
+}
 
-	switch a := a.(type) {
-	case int64:
-		i, ok := b.(int64)
-		if !ok {
-			f, ok := b.(float64)
-			if !ok || float64(a) >= f {
-				return NewTypeMismatchError(a, b)
-			}
-			return nil
-		}
-		if a >= i {
-			return NewNotLesserThanError(a, b)
-		}
-		return nil
-	case float64:
-		f, ok := b.(float64)
+// lt returns nil if a is lesser than b, or an error that describes why it is not.
+func lt(a, b any) error {
+	if x, ok := Numeric(a); ok {
+		y, ok := Numeric(b)
 		if !ok {
-			i, ok := b.(int64)
-			if !ok {
-				return NewTypeMismatchError(a, b)
-			}
-			f = float64(i)
+			return NewTypeMismatchError(a, b)
 		}
-		if a >= f {
+		if compareNumbers(x, y) >= 0 {
 			return NewNotLesserThanError(a, b)
 		}
 		return nil
+	}
+	switch a := a.(type) {
 	case string:
 		s, ok := b.(string)
 		if !ok {
 			return NewTypeMismatchError(a, b)
 		}
+		// Go compares strings byte-wise, which is equivalent to comparing the Unicode scalar values of UTF-8 strings.
 		if a >= s {
 			return NewNotLesserThanError(a, b)
 		}
@@ -258,3 +205,14 @@ func lt(a, b any) error {
 		return NewTypeNotSupportedError(a)
 	}
 }
+
+func toFloat(v any) float64 {
+	switch v := v.(type) {
+	case int64:
+		return float64(v)
+	case float64:
+		return v
+	default:
+		return 0
+	}
+}
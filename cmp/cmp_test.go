@@ -210,3 +210,39 @@ func TestComparisons(t *testing.T) {
 		})
 	}
 }
+
+func TestCompare_nothing(t *testing.T) {
+	for _, test := range []struct {
+		a, b   any
+		op     string
+		result bool
+	}{
+		{cmp.Nothing{}, cmp.Nothing{}, "==", true},
+		{cmp.Nothing{}, cmp.Nothing{}, "!=", false},
+		{cmp.Nothing{}, cmp.Nothing{}, "<=", true},
+		{cmp.Nothing{}, cmp.Nothing{}, "<", false},
+		{cmp.Nothing{}, nil, "==", false},
+		{cmp.Nothing{}, nil, "!=", true},
+		{nil, cmp.Nothing{}, "==", false},
+		{nil, nil, "==", true},
+		{nil, nil, ">=", true},
+		{nil, nil, ">", false},
+		{cmp.Nothing{}, 1, "<", false},
+		{cmp.Nothing{}, 1, ">", false},
+		{cmp.Nothing{}, 1, "!=", true},
+		{1, int64(1), "==", true},
+		{int32(1), 1.0, "==", true},
+		{uint8(2), 1.5, ">", true},
+		{int64(1 << 60), int64(1<<60 + 1), "<", true},
+		{"a", "b", "<", true},
+		{"é", "z", ">", true},
+		{false, false, ">=", true},
+		{false, true, "<", false},
+		{map[string]any{"a": nil}, map[string]any{"b": nil}, "==", false},
+		{map[string]any{"a": 1}, map[string]any{"a": 1.0}, "==", true},
+	} {
+		if err := cmp.Compare(test.a, test.b, test.op); (err == nil) != test.result {
+			t.Errorf("compare(%v, %v, %q) = %v; want %v", test.a, test.b, test.op, err, test.result)
+		}
+	}
+}
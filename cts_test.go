@@ -0,0 +1,100 @@
+package jsonpath_test
+
+import (
+	"encoding/json"
+	"github.com/0x51-dev/jsonpath"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// complianceSkipList contains the names of the compliance tests that are known to fail, each with the reason why. It
+// is rebuilt from the failures of TestCompliance whenever testdata/cts.json is updated.
+var complianceSkipList = map[string]string{}
+
+type complianceTest struct {
+	Name            string     `json:"name"`
+	Selector        string     `json:"selector"`
+	Document        any        `json:"document"`
+	Result          []any      `json:"result"`
+	Results         [][]any    `json:"results"`
+	ResultPaths     []string   `json:"result_paths"`
+	ResultsPaths    [][]string `json:"results_paths"`
+	InvalidSelector bool       `json:"invalid_selector"`
+}
+
+// Run checks the test against the query, the result is either deterministic (result) or one of the given alternatives
+// (results).
+func (c complianceTest) Run(t *testing.T) {
+	t.Run(c.Name, func(t *testing.T) {
+		if reason, ok := complianceSkipList[c.Name]; ok {
+			t.Skip(reason)
+		}
+		q, err := jsonpath.New(c.Selector)
+		if c.InvalidSelector {
+			if err == nil {
+				t.Fatalf("expected %q to be invalid", c.Selector)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		nodes := q.ApplyLocated(c.Document)
+		values := []any(nodes.Nodes())
+		if values == nil {
+			values = []any{}
+		}
+		paths := make([]string, 0, len(nodes))
+		for _, location := range nodes.Locations() {
+			paths = append(paths, location.String())
+		}
+
+		results, resultsPaths := c.Results, c.ResultsPaths
+		if c.Results == nil {
+			results = [][]any{c.Result}
+			if c.ResultPaths != nil {
+				resultsPaths = [][]string{c.ResultPaths}
+			}
+		}
+		for i, result := range results {
+			if result == nil {
+				result = []any{}
+			}
+			if !reflect.DeepEqual(values, result) {
+				continue
+			}
+			if resultsPaths != nil && !reflect.DeepEqual(paths, resultsPaths[i]) {
+				t.Errorf("expected paths %v, got %v", resultsPaths[i], paths)
+			}
+			return
+		}
+		t.Errorf("expected one of %v, got %v", results, values)
+	})
+}
+
+// TestCompliance runs the official suite, vendored at the commit recorded in testdata/cts.commit by
+// `make cts CTS_COMMIT=<commit>`. It fails if the suite is not vendored.
+//
+// https://github.com/jsonpath-standard/jsonpath-compliance-test-suite
+func TestCompliance(t *testing.T) {
+	for _, name := range []string{"testdata/cts.commit", "testdata/cts.json"} {
+		if _, err := os.Stat(name); err != nil {
+			t.Fatalf("the official suite is not vendored, run `make cts CTS_COMMIT=<commit>`: %v", err)
+		}
+	}
+	raw, err := os.ReadFile("testdata/cts.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var suite struct {
+		Tests []complianceTest `json:"tests"`
+	}
+	if err := json.Unmarshal(raw, &suite); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range suite.Tests {
+		test.Run(t)
+	}
+}
@@ -0,0 +1,34 @@
+// Package jsonfn provides a function extension to decode JSON in strings, e.g. $.events[?parse_json(@.body) == 'ok'].
+package jsonfn
+
+import (
+	"encoding/json"
+	"github.com/0x51-dev/jsonpath"
+	"github.com/0x51-dev/jsonpath/cmp"
+)
+
+// Functions returns the JSON function extensions:
+//   - parse_json(s) returns the value of the JSON text in the string, or cmp.Nothing if it is not valid JSON.
+//     To select values within embedded JSON, see jsonpath.WithEmbeddedJSON instead.
+func Functions() []jsonpath.Function {
+	return []jsonpath.Function{
+		{
+			Name:       "parse_json",
+			Parameters: []jsonpath.Type{jsonpath.ValueType},
+			Result:     jsonpath.ValueType,
+			Call:       parseJSON,
+		},
+	}
+}
+
+func parseJSON(args []any) any {
+	s, ok := args[0].(string)
+	if !ok {
+		return cmp.Nothing{}
+	}
+	var v any
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		return cmp.Nothing{}
+	}
+	return v
+}
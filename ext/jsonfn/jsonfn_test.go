@@ -0,0 +1,27 @@
+package jsonfn_test
+
+import (
+	"github.com/0x51-dev/jsonpath"
+	"github.com/0x51-dev/jsonpath/ext/jsonfn"
+	"github.com/0x51-dev/jsonpath/internal/exttest"
+	"testing"
+)
+
+func TestFunctions(t *testing.T) {
+	example := []any{
+		map[string]any{"id": 1, "body": `"ok"`},
+		map[string]any{"id": 2, "body": `{"status": "ok", "codes": [1, 2]}`},
+		map[string]any{"id": 3, "body": `[1, 2]`},
+		map[string]any{"id": 4, "body": `{"status": `},
+		map[string]any{"id": 5, "body": 2},
+		map[string]any{"id": 6, "body": "2.0"},
+	}
+	exttest.Cases{
+		{Query: "$[?parse_json(@.body) == 'ok'].id", Result: jsonpath.NodeList{1}},
+		{Query: "$[?parse_json(@.body) == 2].id", Result: jsonpath.NodeList{6}},
+		{Query: "$[?length(parse_json(@.body)) == 2].id", Result: jsonpath.NodeList{1, 2, 3}},
+		{Query: "$[?parse_json(@.body) == parse_json('[1,2]')].id", Result: jsonpath.NodeList{3}},
+		{Query: "$[?parse_json(@.nothing) == parse_json(@.body)].id", Result: jsonpath.NodeList{4, 5}},
+		{Query: "$[?parse_json(@.body)]", Invalid: true},
+	}.Run(t, jsonfn.Functions(), example)
+}
@@ -0,0 +1,97 @@
+// Package nodefn provides function extensions that return node lists, e.g. $[?count(flatten(@.groups[*])) > 10].
+package nodefn
+
+import (
+	"github.com/0x51-dev/jsonpath"
+	"github.com/0x51-dev/jsonpath/cmp"
+)
+
+// Functions returns the node list function extensions:
+//   - flatten(nodes) replaces the arrays among the nodes by their elements.
+//   - descendants(nodes) returns the descendants of the nodes in document order, like the descendant segment ..*.
+//   - distinct(nodes) returns the nodes without duplicates, keeping the first occurrence of equal values.
+func Functions() []jsonpath.Function {
+	return []jsonpath.Function{
+		nodes("flatten", flatten),
+		nodes("descendants", descendants),
+		nodes("distinct", distinct),
+	}
+}
+
+// children returns the elements of an array, or the member values of an object in the order of Keys.
+func children(v any) jsonpath.NodeList {
+	n, ok := jsonpath.NodeOf(v)
+	if !ok {
+		return nil
+	}
+	var nodeList jsonpath.NodeList
+	switch n.Kind() {
+	case jsonpath.ArrayKind:
+		for i := 0; i < n.Len(); i++ {
+			e, _ := n.Index(i)
+			nodeList = append(nodeList, e)
+		}
+	case jsonpath.ObjectKind:
+		for _, name := range n.Keys() {
+			m, _ := n.Member(name)
+			nodeList = append(nodeList, m)
+		}
+	}
+	return nodeList
+}
+
+func descendants(nodeList jsonpath.NodeList) jsonpath.NodeList {
+	var result jsonpath.NodeList
+	for _, node := range nodeList {
+		for _, child := range children(node) {
+			result = append(result, child)
+			result = append(result, descendants(jsonpath.NodeList{child})...)
+		}
+	}
+	return result
+}
+
+func distinct(nodeList jsonpath.NodeList) jsonpath.NodeList {
+	var result jsonpath.NodeList
+	var values []any
+	for _, node := range nodeList {
+		value := jsonpath.JSONValue(node)
+		var duplicate bool
+		for _, other := range values {
+			if cmp.Equal(value, other) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			result = append(result, node)
+			values = append(values, value)
+		}
+	}
+	return result
+}
+
+func flatten(nodeList jsonpath.NodeList) jsonpath.NodeList {
+	var result jsonpath.NodeList
+	for _, node := range nodeList {
+		if n, ok := jsonpath.NodeOf(node); ok && n.Kind() == jsonpath.ArrayKind {
+			result = append(result, children(n)...)
+			continue
+		}
+		result = append(result, node)
+	}
+	return result
+}
+
+// nodes returns a function of NodesType that maps a node list to a node list.
+func nodes(name string, f func(nodeList jsonpath.NodeList) jsonpath.NodeList) jsonpath.Function {
+	return jsonpath.Function{
+		Name:       name,
+		Parameters: []jsonpath.Type{jsonpath.NodesType},
+		Result:     jsonpath.NodesType,
+		Call: func(args []any) any {
+			nodeList, _ := args[0].(jsonpath.NodeList)
+			return f(nodeList)
+		},
+	}
+}
@@ -0,0 +1,63 @@
+package nodefn_test
+
+import (
+	"github.com/0x51-dev/jsonpath"
+	"github.com/0x51-dev/jsonpath/ext/nodefn"
+	"github.com/0x51-dev/jsonpath/internal/exttest"
+	"reflect"
+	"testing"
+)
+
+func TestFunctions(t *testing.T) {
+	example := []any{
+		map[string]any{"id": 1, "groups": []any{[]any{"a", "b"}, []any{"c"}, "d"}},
+		map[string]any{"id": 2, "groups": []any{[]any{"a"}, []any{"a"}}},
+		map[string]any{"id": 3, "groups": []any{}},
+		map[string]any{"id": 4, "tree": map[string]any{"b": []any{map[string]any{"color": "red"}}, "a": "x"}},
+	}
+	exttest.Cases{
+		{Query: "$[?count(flatten(@.groups[*])) == 4].id", Result: jsonpath.NodeList{1}},
+		{Query: "$[?count(distinct(flatten(@.groups[*]))) == 1].id", Result: jsonpath.NodeList{2}},
+		{Query: "$[?flatten(@.groups[*])].id", Result: jsonpath.NodeList{1, 2}},
+		{Query: "$[?!flatten(@.groups[*])].id", Result: jsonpath.NodeList{3, 4}},
+		{Query: "$[?count(descendants(@)) == count(@..*)].id", Result: jsonpath.NodeList{1, 2, 3, 4}},
+		{Query: "$[?value(descendants(@.tree)) == 'x'].id"},
+		{Query: "$[?count(descendants(@.tree)) == 4].id", Result: jsonpath.NodeList{4}},
+		{Query: "$[?length(descendants(@.tree)) == 4]", Invalid: true},
+		{Query: "$[?flatten(@.groups[*]) == 1]", Invalid: true},
+		{Query: "$[?distinct(1)]", Invalid: true},
+	}.Run(t, nodefn.Functions(), example)
+}
+
+// members is an object that returns its internal slice of names from Keys.
+type members struct {
+	names  []string
+	values []any
+}
+
+func (m *members) Kind() jsonpath.Kind { return jsonpath.ObjectKind }
+
+func (m *members) Member(name string) (any, bool) {
+	for i, n := range m.names {
+		if n == name {
+			return m.values[i], true
+		}
+	}
+	return nil, false
+}
+
+func (m *members) Index(int) (any, bool) { return nil, false }
+func (m *members) Len() int              { return len(m.names) }
+func (m *members) Keys() []string        { return m.names }
+func (m *members) Value() any            { return nil }
+
+// The functions must not modify the member names returned by Keys.
+func TestFunctions_keys(t *testing.T) {
+	example := []any{&members{names: []string{"z", "a"}, values: []any{"zval", "aval"}}}
+	exttest.Cases{
+		{Query: "$[?count(descendants(@)) > 0 && count(flatten(@.*)) > 0]", Result: jsonpath.NodeList{example[0]}},
+	}.Run(t, nodefn.Functions(), example)
+	if names := example[0].(*members).names; !reflect.DeepEqual(names, []string{"z", "a"}) {
+		t.Errorf("expected the names to be unchanged, got %v", names)
+	}
+}
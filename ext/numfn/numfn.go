@@ -0,0 +1,138 @@
+// Package numfn provides numeric function extensions, e.g. $.orders[?sum(@.lines[*].amount) > 1000].
+package numfn
+
+import (
+	"github.com/0x51-dev/jsonpath"
+	"github.com/0x51-dev/jsonpath/cmp"
+	"math"
+)
+
+// Functions returns the numeric function extensions. Numbers are normalized with cmp.Numeric, and integers remain
+// integers as long as the result is exact:
+//   - sum(nodes), min(nodes), max(nodes) and avg(nodes) aggregate the numbers selected by a query. The sum of no
+//     numbers is 0, the other aggregates of no numbers are Nothing.
+//   - abs(n), floor(n), ceil(n) and round(n) return the absolute value, or the number rounded down, up or to the
+//     nearest integer (halfway away from zero).
+func Functions() []jsonpath.Function {
+	return []jsonpath.Function{
+		aggregate("sum", sum),
+		aggregate("min", extreme(-1)),
+		aggregate("max", extreme(1)),
+		aggregate("avg", avg),
+		scalar("abs", abs, math.Abs),
+		scalar("floor", identity, math.Floor),
+		scalar("ceil", identity, math.Ceil),
+		scalar("round", identity, math.Round),
+	}
+}
+
+func abs(i int64) any {
+	if i == math.MinInt64 {
+		return -float64(i)
+	}
+	if i < 0 {
+		return -i
+	}
+	return i
+}
+
+// aggregate returns a function that aggregates the numbers of a node list. It returns Nothing if any node is not a
+// number.
+func aggregate(name string, f func(numbers []any) any) jsonpath.Function {
+	return jsonpath.Function{
+		Name:       name,
+		Parameters: []jsonpath.Type{jsonpath.NodesType},
+		Result:     jsonpath.ValueType,
+		Call: func(args []any) any {
+			nodeList, _ := args[0].(jsonpath.NodeList)
+			numbers := make([]any, len(nodeList))
+			for i, node := range nodeList {
+				n, ok := cmp.Numeric(jsonpath.JSONValue(node))
+				if !ok {
+					return cmp.Nothing{}
+				}
+				numbers[i] = n
+			}
+			return f(numbers)
+		},
+	}
+}
+
+func avg(numbers []any) any {
+	if len(numbers) == 0 {
+		return cmp.Nothing{}
+	}
+	var total float64
+	for _, n := range numbers {
+		total += toFloat(n)
+	}
+	return total / float64(len(numbers))
+}
+
+// extreme returns a function that returns the smallest (sign -1) or largest (sign 1) number.
+func extreme(sign int) func(numbers []any) any {
+	return func(numbers []any) any {
+		if len(numbers) == 0 {
+			return cmp.Nothing{}
+		}
+		result := numbers[0]
+		for _, n := range numbers[1:] {
+			if sign < 0 && cmp.Less(n, result) || 0 < sign && cmp.Less(result, n) {
+				result = n
+			}
+		}
+		return result
+	}
+}
+
+func identity(i int64) any {
+	return i
+}
+
+// scalar returns a function that maps a number to a number, with separate implementations for integers and floating
+// point numbers.
+func scalar(name string, i func(int64) any, f func(float64) float64) jsonpath.Function {
+	return jsonpath.Function{
+		Name:       name,
+		Parameters: []jsonpath.Type{jsonpath.ValueType},
+		Result:     jsonpath.ValueType,
+		Call: func(args []any) any {
+			switch n, _ := cmp.Numeric(args[0]); n := n.(type) {
+			case int64:
+				return i(n)
+			case float64:
+				return f(n)
+			default:
+				return cmp.Nothing{}
+			}
+		},
+	}
+}
+
+// sum returns the sum of the numbers. It is an integer if all numbers are integers and the sum does not overflow.
+func sum(numbers []any) any {
+	var total int64
+	for i, n := range numbers {
+		j, ok := n.(int64)
+		if !ok || (0 < j && math.MaxInt64-j < total) || (j < 0 && total < math.MinInt64-j) {
+			result := float64(total)
+			for _, n := range numbers[i:] {
+				result += toFloat(n)
+			}
+			return result
+		}
+		total += j
+	}
+	return total
+}
+
+func toFloat(n any) float64 {
+	switch n := n.(type) {
+	case int64:
+		return float64(n)
+	case float64:
+		return n
+	default:
+		return math.NaN()
+	}
+}
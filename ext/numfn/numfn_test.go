@@ -0,0 +1,36 @@
+package numfn_test
+
+import (
+	"encoding/json"
+	"github.com/0x51-dev/jsonpath"
+	"github.com/0x51-dev/jsonpath/ext/numfn"
+	"github.com/0x51-dev/jsonpath/internal/exttest"
+	"testing"
+)
+
+func TestFunctions(t *testing.T) {
+	example := []any{
+		map[string]any{"id": 1, "lines": []any{map[string]any{"amount": 600}, map[string]any{"amount": 500.5}}},
+		map[string]any{"id": 2, "lines": []any{map[string]any{"amount": int8(100)}, map[string]any{"amount": json.Number("-3")}}},
+		map[string]any{"id": 3, "lines": []any{map[string]any{"amount": "1000"}}},
+		map[string]any{"id": 4, "lines": []any{}},
+	}
+	exttest.Cases{
+		{Query: "$[?sum(@.lines[*].amount) > 1000].id", Result: jsonpath.NodeList{1}},
+		{Query: "$[?sum(@.lines[*].amount) == 97].id", Result: jsonpath.NodeList{2}},
+		{Query: "$[?sum(@.lines[*].amount) == 0].id", Result: jsonpath.NodeList{4}},
+		{Query: "$[?min(@.lines[*].amount) == -3].id", Result: jsonpath.NodeList{2}},
+		{Query: "$[?max(@.lines[*].amount) == 600].id", Result: jsonpath.NodeList{1}},
+		{Query: "$[?avg(@.lines[*].amount) == 48.5].id", Result: jsonpath.NodeList{2}},
+		{Query: "$[?avg(@.lines[*].amount) == max(@.missing[*])].id", Result: jsonpath.NodeList{3, 4}},
+		{Query: "$[?abs(@.lines[1].amount) == 3].id", Result: jsonpath.NodeList{2}},
+		{Query: "$[?floor(@.lines[1].amount) == 500].id", Result: jsonpath.NodeList{1}},
+		{Query: "$[?ceil(@.lines[1].amount) == 501].id", Result: jsonpath.NodeList{1}},
+		{Query: "$[?round(@.lines[1].amount) == 501].id", Result: jsonpath.NodeList{1}},
+		{Query: "$[?round(-2.5) == -3].id", Result: jsonpath.NodeList{1, 2, 3, 4}},
+		{Query: "$[?abs(@.lines[0].amount) == 1000].id"},
+		{Query: "$[?sum(@.lines[0].amount) == 600].id", Result: jsonpath.NodeList{1}},
+		{Query: "$[?sum(1) == 1]", Invalid: true},
+		{Query: "$[?abs(@.lines[*].amount) == 1]", Invalid: true},
+	}.Run(t, numfn.Functions(), example)
+}
@@ -0,0 +1,179 @@
+// Package stringfn provides string function extensions, e.g. $[?lower(@.email) == 'x@y.z'].
+package stringfn
+
+import (
+	"github.com/0x51-dev/jsonpath"
+	"github.com/0x51-dev/jsonpath/cmp"
+	"math"
+	"strings"
+)
+
+// Functions returns the string function extensions. Lengths and positions are counted in Unicode scalar values, like
+// the standard length function:
+//   - lower(s), upper(s) and trim(s) return the string in lower case, in upper case or without leading and trailing
+//     white space.
+//   - starts_with(s, prefix), ends_with(s, suffix) and contains(s, substr) are true if the string starts with, ends
+//     with or contains the other string.
+//   - substring(s, start, end) returns the characters of the string from start up to end (exclusive). Both are
+//     clamped to the length of the string.
+//   - split(s, sep) returns the array of substrings separated by sep, join(a, sep) returns the strings of the array
+//     joined by sep.
+//   - concat(a, b) returns the concatenation of both strings.
+func Functions() []jsonpath.Function {
+	return []jsonpath.Function{
+		unary("lower", strings.ToLower),
+		unary("upper", strings.ToUpper),
+		unary("trim", strings.TrimSpace),
+		predicate("starts_with", strings.HasPrefix),
+		predicate("ends_with", strings.HasSuffix),
+		predicate("contains", strings.Contains),
+		{
+			Name:       "substring",
+			Parameters: []jsonpath.Type{jsonpath.ValueType, jsonpath.ValueType, jsonpath.ValueType},
+			Result:     jsonpath.ValueType,
+			Call:       substring,
+		},
+		{
+			Name:       "split",
+			Parameters: []jsonpath.Type{jsonpath.ValueType, jsonpath.ValueType},
+			Result:     jsonpath.ValueType,
+			Call:       split,
+		},
+		{
+			Name:       "join",
+			Parameters: []jsonpath.Type{jsonpath.ValueType, jsonpath.ValueType},
+			Result:     jsonpath.ValueType,
+			Call:       join,
+		},
+		{
+			Name:       "concat",
+			Parameters: []jsonpath.Type{jsonpath.ValueType, jsonpath.ValueType},
+			Result:     jsonpath.ValueType,
+			Call:       concat,
+		},
+	}
+}
+
+func concat(args []any) any {
+	a, ok := args[0].(string)
+	if !ok {
+		return cmp.Nothing{}
+	}
+	b, ok := args[1].(string)
+	if !ok {
+		return cmp.Nothing{}
+	}
+	return a + b
+}
+
+// integer returns the value as an int, if it is a number without a fractional part. Large values are clamped, since
+// they are only used as positions within a string.
+func integer(v any) (int, bool) {
+	n, ok := cmp.Numeric(v)
+	if !ok {
+		return 0, false
+	}
+	switch n := n.(type) {
+	case int64:
+		return int(n), true
+	case float64:
+		if n != math.Trunc(n) {
+			return 0, false
+		}
+		return int(math.Max(math.Min(n, math.MaxInt32), math.MinInt32)), true
+	default:
+		return 0, false
+	}
+}
+
+func join(args []any) any {
+	a, ok := args[0].([]any)
+	if !ok {
+		return cmp.Nothing{}
+	}
+	sep, ok := args[1].(string)
+	if !ok {
+		return cmp.Nothing{}
+	}
+	elems := make([]string, len(a))
+	for i, e := range a {
+		s, ok := e.(string)
+		if !ok {
+			return cmp.Nothing{}
+		}
+		elems[i] = s
+	}
+	return strings.Join(elems, sep)
+}
+
+// predicate returns a function of LogicalType that compares two strings.
+func predicate(name string, f func(s, t string) bool) jsonpath.Function {
+	return jsonpath.Function{
+		Name:       name,
+		Parameters: []jsonpath.Type{jsonpath.ValueType, jsonpath.ValueType},
+		Result:     jsonpath.LogicalType,
+		Call: func(args []any) any {
+			s, ok := args[0].(string)
+			if !ok {
+				return false
+			}
+			t, ok := args[1].(string)
+			if !ok {
+				return false
+			}
+			return f(s, t)
+		},
+	}
+}
+
+func split(args []any) any {
+	s, ok := args[0].(string)
+	if !ok {
+		return cmp.Nothing{}
+	}
+	sep, ok := args[1].(string)
+	if !ok {
+		return cmp.Nothing{}
+	}
+	parts := strings.Split(s, sep)
+	a := make([]any, len(parts))
+	for i, p := range parts {
+		a[i] = p
+	}
+	return a
+}
+
+func substring(args []any) any {
+	s, ok := args[0].(string)
+	if !ok {
+		return cmp.Nothing{}
+	}
+	start, ok := integer(args[1])
+	if !ok {
+		return cmp.Nothing{}
+	}
+	end, ok := integer(args[2])
+	if !ok {
+		return cmp.Nothing{}
+	}
+	r := []rune(s)
+	start = min(max(start, 0), len(r))
+	end = min(max(end, start), len(r))
+	return string(r[start:end])
+}
+
+// unary returns a function of ValueType that maps a string to a string.
+func unary(name string, f func(s string) string) jsonpath.Function {
+	return jsonpath.Function{
+		Name:       name,
+		Parameters: []jsonpath.Type{jsonpath.ValueType},
+		Result:     jsonpath.ValueType,
+		Call: func(args []any) any {
+			s, ok := args[0].(string)
+			if !ok {
+				return cmp.Nothing{}
+			}
+			return f(s)
+		},
+	}
+}
@@ -0,0 +1,34 @@
+package stringfn_test
+
+import (
+	"github.com/0x51-dev/jsonpath"
+	"github.com/0x51-dev/jsonpath/ext/stringfn"
+	"github.com/0x51-dev/jsonpath/internal/exttest"
+	"testing"
+)
+
+func TestFunctions(t *testing.T) {
+	example := []any{
+		map[string]any{"email": "X@Y.z", "name": " Ada Lovelace ", "tags": []any{"a", "b"}},
+		map[string]any{"email": "a@b.c", "name": "Grace Hopper", "tags": []any{"c", 1.0}},
+		map[string]any{"email": 1.0, "name": "Ελληνικά"},
+	}
+	exttest.Cases{
+		{Query: "$[?lower(@.email) == 'x@y.z'].name", Result: jsonpath.NodeList{" Ada Lovelace "}},
+		{Query: "$[?upper(@.email) == 'A@B.C'].name", Result: jsonpath.NodeList{"Grace Hopper"}},
+		{Query: "$[?trim(@.name) == 'Ada Lovelace'].email", Result: jsonpath.NodeList{"X@Y.z"}},
+		{Query: "$[?starts_with(@.name, 'Grace')].email", Result: jsonpath.NodeList{"a@b.c"}},
+		{Query: "$[?ends_with(@.email, '.z')].email", Result: jsonpath.NodeList{"X@Y.z"}},
+		{Query: "$[?!contains(@.email, '@')].email", Result: jsonpath.NodeList{1.0}},
+		{Query: "$[?substring(@.name, 0, 5) == 'Grace'].email", Result: jsonpath.NodeList{"a@b.c"}},
+		{Query: "$[?substring(@.name, 2, 100) == 'ληνικά'].email", Result: jsonpath.NodeList{1.0}},
+		{Query: "$[?substring(@.name, 0.5, 1) == ''].email"},
+		{Query: "$[?split(@.email, '@') == ['a', 'b.c']].name", Invalid: true},
+		{Query: "$[?value(split(@.email, '@')) == 'a'].name", Invalid: true},
+		{Query: "$[?length(split(@.email, '@')) == 2].name", Result: jsonpath.NodeList{" Ada Lovelace ", "Grace Hopper"}},
+		{Query: "$[?join(@.tags, ',') == 'a,b'].email", Result: jsonpath.NodeList{"X@Y.z"}},
+		{Query: "$[?join(@.tags, ',')].email", Invalid: true},
+		{Query: "$[?concat(@.email, '!') == 'a@b.c!'].name", Result: jsonpath.NodeList{"Grace Hopper"}},
+		{Query: "$[?length(concat(@.name, @.email)) == 9].name"},
+	}.Run(t, stringfn.Functions(), example)
+}
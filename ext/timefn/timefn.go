@@ -0,0 +1,147 @@
+// Package timefn provides function extensions for timestamps, e.g. $.events[?date_diff(now(), @.createdAt) < 86400].
+package timefn
+
+import (
+	"fmt"
+	"github.com/0x51-dev/jsonpath"
+	"github.com/0x51-dev/jsonpath/cmp"
+	"math"
+	"time"
+)
+
+// Option configures the function extensions.
+type Option func(*options)
+
+// WithClock sets the clock used by now(), which is time.Now by default.
+func WithClock(now func() time.Time) Option {
+	return func(o *options) {
+		o.now = now
+	}
+}
+
+type options struct {
+	now func() time.Time
+}
+
+// Functions returns the time function extensions. Points in time are RFC 3339 strings or numbers of seconds since the
+// Unix epoch:
+//   - timestamp(t) returns the seconds since the Unix epoch of a point in time.
+//   - now() returns the seconds since the Unix epoch of the current time.
+//   - date_diff(t, u) returns the seconds from u to t, i.e. t - u.
+//   - before(t, u) and after(t, u) are true if t is before or after u.
+func Functions(opts ...Option) []jsonpath.Function {
+	o := options{now: time.Now}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return []jsonpath.Function{
+		{
+			Name:       "timestamp",
+			Parameters: []jsonpath.Type{jsonpath.ValueType},
+			Result:     jsonpath.ValueType,
+			Call: func(args []any) any {
+				t, ok := instant(args[0])
+				if !ok {
+					return cmp.Nothing{}
+				}
+				return epoch(t)
+			},
+			Validate: validateTimestamp,
+		},
+		{
+			Name:   "now",
+			Result: jsonpath.ValueType,
+			Call: func(args []any) any {
+				return epoch(o.now())
+			},
+		},
+		{
+			Name:       "date_diff",
+			Parameters: []jsonpath.Type{jsonpath.ValueType, jsonpath.ValueType},
+			Result:     jsonpath.ValueType,
+			Call:       dateDiff,
+			Validate:   validateTimestamp,
+		},
+		order("before", -1),
+		order("after", 1),
+	}
+}
+
+func dateDiff(args []any) any {
+	t, ok := instant(args[0])
+	if !ok {
+		return cmp.Nothing{}
+	}
+	u, ok := instant(args[1])
+	if !ok {
+		return cmp.Nothing{}
+	}
+	// A time.Duration only covers about 292 years, so the difference is not computed with t.Sub(u).
+	return seconds(t.Unix()-u.Unix(), int64(t.Nanosecond()-u.Nanosecond()))
+}
+
+// epoch returns the seconds since the Unix epoch, as an integer if there is no fractional part.
+func epoch(t time.Time) any {
+	return seconds(t.Unix(), int64(t.Nanosecond()))
+}
+
+// seconds returns sec seconds plus nsec nanoseconds, as an integer if there is no fractional part.
+func seconds(sec, nsec int64) any {
+	if nsec == 0 {
+		return sec
+	}
+	return float64(sec) + float64(nsec)/float64(time.Second)
+}
+
+// instant returns the point in time of an RFC 3339 timestamp or a number of seconds since the Unix epoch.
+func instant(v any) (time.Time, bool) {
+	if s, ok := v.(string); ok {
+		t, err := time.Parse(time.RFC3339Nano, s)
+		return t, err == nil
+	}
+	switch n, _ := cmp.Numeric(v); n := n.(type) {
+	case int64:
+		return time.Unix(n, 0), true
+	case float64:
+		if math.IsNaN(n) || n < math.MinInt64 || math.MaxInt64 <= n {
+			return time.Time{}, false
+		}
+		sec := math.Floor(n)
+		return time.Unix(int64(sec), int64((n-sec)*float64(time.Second))), true
+	default:
+		return time.Time{}, false
+	}
+}
+
+// order returns a function of LogicalType that compares two points in time.
+func order(name string, sign int) jsonpath.Function {
+	return jsonpath.Function{
+		Name:       name,
+		Parameters: []jsonpath.Type{jsonpath.ValueType, jsonpath.ValueType},
+		Result:     jsonpath.LogicalType,
+		Call: func(args []any) any {
+			t, ok := instant(args[0])
+			if !ok {
+				return false
+			}
+			u, ok := instant(args[1])
+			if !ok {
+				return false
+			}
+			return t.Compare(u) == sign
+		},
+		Validate: validateTimestamp,
+	}
+}
+
+// validateTimestamp checks that literal string arguments are valid RFC 3339 timestamps.
+func validateTimestamp(args []any) error {
+	for _, arg := range args {
+		if s, ok := arg.(string); ok {
+			if _, err := time.Parse(time.RFC3339Nano, s); err != nil {
+				return fmt.Errorf("invalid timestamp %q: %w", s, err)
+			}
+		}
+	}
+	return nil
+}
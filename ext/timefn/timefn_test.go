@@ -0,0 +1,47 @@
+package timefn_test
+
+import (
+	"github.com/0x51-dev/jsonpath"
+	"github.com/0x51-dev/jsonpath/ext/timefn"
+	"github.com/0x51-dev/jsonpath/internal/exttest"
+	"testing"
+	"time"
+)
+
+func TestFunctions(t *testing.T) {
+	clock := timefn.WithClock(func() time.Time {
+		return time.Date(2024, 3, 2, 12, 0, 0, 0, time.UTC)
+	})
+	example := []any{
+		map[string]any{"id": 1, "createdAt": "2024-03-02T11:30:00Z"},
+		map[string]any{"id": 2, "createdAt": "2024-03-02T00:00:00.5+01:00"},
+		map[string]any{"id": 3, "createdAt": "2024-02-29T12:00:00Z"},
+		map[string]any{"id": 4, "createdAt": "yesterday"},
+		map[string]any{"id": 5, "createdAt": 1709380800},
+	}
+	exttest.Cases{
+		{Query: "$[?date_diff(now(), @.createdAt) < 86400].id", Result: jsonpath.NodeList{1, 2, 5}},
+		{Query: "$[?date_diff(now(), @.createdAt) == 46799.5].id", Result: jsonpath.NodeList{2}},
+		{Query: "$[?timestamp(@.createdAt) < timestamp('2024-03-01T00:00:00Z')].id", Result: jsonpath.NodeList{3}},
+		{Query: "$[?timestamp(@.createdAt) == 1709380800].id", Result: jsonpath.NodeList{5}},
+		{Query: "$[?timestamp(@.createdAt) == 1709379000].id", Result: jsonpath.NodeList{1}},
+		{Query: "$[?now() == 1709380800].id", Result: jsonpath.NodeList{1, 2, 3, 4, 5}},
+		{Query: "$[?before(@.createdAt, '2024-03-01T00:00:00Z')].id", Result: jsonpath.NodeList{3}},
+		{Query: "$[?after(@.createdAt, '2024-03-01T00:00:00Z')].id", Result: jsonpath.NodeList{1, 2, 5}},
+		{Query: "$[?!before(@.createdAt, now()) && !after(@.createdAt, now())].id", Result: jsonpath.NodeList{4, 5}},
+		{
+			Query:  "$[?date_diff('2500-01-01T00:00:00Z', '1970-01-01T00:00:00Z') == timestamp('2500-01-01T00:00:00Z')].id",
+			Result: jsonpath.NodeList{1, 2, 3, 4, 5},
+		},
+		{
+			Query:  "$[?timestamp('3000-01-01T00:00:00.5Z') > timestamp('2500-01-01T00:00:00Z')].id",
+			Result: jsonpath.NodeList{1, 2, 3, 4, 5},
+		},
+		{Query: "$[?timestamp('3000-01-01T00:00:00.5Z') == 32503680000.5].id", Result: jsonpath.NodeList{1, 2, 3, 4, 5}},
+		{Query: "$[?after(32503680000.5, '2500-01-01T00:00:00Z')].id", Result: jsonpath.NodeList{1, 2, 3, 4, 5}},
+		{Query: "$[?after(32503680000.5, '3000-01-01T00:00:00.6Z')].id"},
+		{Query: "$[?timestamp('yesterday') == 0]", Invalid: true},
+		{Query: "$[?before(@.createdAt, '2024-03-01')]", Invalid: true},
+		{Query: "$[?now(@) == 0]", Invalid: true},
+	}.Run(t, timefn.Functions(clock), example)
+}
@@ -0,0 +1,106 @@
+// Package typefn provides function extensions that inspect the type of a value, e.g. $[?type(@.id) == 'string'].
+package typefn
+
+import (
+	"github.com/0x51-dev/jsonpath"
+	"github.com/0x51-dev/jsonpath/cmp"
+	"math"
+	"sort"
+)
+
+// Functions returns the type function extensions:
+//   - type(v) returns the JSON type of the value: "null", "boolean", "number", "string", "array" or "object".
+//     A missing value has no type, so it is distinct from null.
+//   - is_integer(v) is true if the value is a number without a fractional part.
+//   - keys(v) returns the array of member names of an object, sorted in ascending order.
+//   - has(v, name) is true if the value is an object with a member of the given name.
+func Functions() []jsonpath.Function {
+	return []jsonpath.Function{
+		{
+			Name:       "type",
+			Parameters: []jsonpath.Type{jsonpath.ValueType},
+			Result:     jsonpath.ValueType,
+			Call:       typeOf,
+		},
+		{
+			Name:       "is_integer",
+			Parameters: []jsonpath.Type{jsonpath.ValueType},
+			Result:     jsonpath.LogicalType,
+			Call:       isInteger,
+		},
+		{
+			Name:       "keys",
+			Parameters: []jsonpath.Type{jsonpath.ValueType},
+			Result:     jsonpath.ValueType,
+			Call:       keys,
+		},
+		{
+			Name:       "has",
+			Parameters: []jsonpath.Type{jsonpath.ValueType, jsonpath.ValueType},
+			Result:     jsonpath.LogicalType,
+			Call:       has,
+		},
+	}
+}
+
+func has(args []any) any {
+	o, ok := args[0].(map[string]any)
+	if !ok {
+		return false
+	}
+	name, ok := args[1].(string)
+	if !ok {
+		return false
+	}
+	_, ok = o[name]
+	return ok
+}
+
+func isInteger(args []any) any {
+	switch n, _ := cmp.Numeric(args[0]); n := n.(type) {
+	case int64:
+		return true
+	case float64:
+		return !math.IsInf(n, 0) && n == math.Trunc(n)
+	default:
+		return false
+	}
+}
+
+func keys(args []any) any {
+	o, ok := args[0].(map[string]any)
+	if !ok {
+		return cmp.Nothing{}
+	}
+	names := make([]string, 0, len(o))
+	for name := range o {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	a := make([]any, len(names))
+	for i, name := range names {
+		a[i] = name
+	}
+	return a
+}
+
+// typeOf returns the name of the JSON type of the value, or cmp.Nothing if there is no value.
+func typeOf(args []any) any {
+	switch v := args[0].(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		if _, ok := cmp.Numeric(v); ok {
+			return "number"
+		}
+		return cmp.Nothing{}
+	}
+}
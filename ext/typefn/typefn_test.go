@@ -0,0 +1,46 @@
+package typefn_test
+
+import (
+	"github.com/0x51-dev/jsonpath"
+	"github.com/0x51-dev/jsonpath/ext/typefn"
+	"github.com/0x51-dev/jsonpath/internal/exttest"
+	"testing"
+)
+
+func TestFunctions(t *testing.T) {
+	example := []any{
+		map[string]any{"id": 1, "x": nil},
+		map[string]any{"id": 2, "x": true},
+		map[string]any{"id": 3, "x": 1.5},
+		map[string]any{"id": 4, "x": int8(2)},
+		map[string]any{"id": 5, "x": 3.0},
+		map[string]any{"id": 6, "x": "3"},
+		map[string]any{"id": 7, "x": []any{}},
+		map[string]any{"id": 8, "x": map[string]any{"b": 1, "a": nil}},
+		map[string]any{"id": 9},
+	}
+	exttest.Cases{
+		{Query: "$[?type(@.x) == 'null'].id", Result: jsonpath.NodeList{1}},
+		{Query: "$[?type(@.x) == 'boolean'].id", Result: jsonpath.NodeList{2}},
+		{Query: "$[?type(@.x) == 'number'].id", Result: jsonpath.NodeList{3, 4, 5}},
+		{Query: "$[?type(@.x) == 'string'].id", Result: jsonpath.NodeList{6}},
+		{Query: "$[?type(@.x) == 'array'].id", Result: jsonpath.NodeList{7}},
+		{Query: "$[?type(@.x) == 'object'].id", Result: jsonpath.NodeList{8}},
+		{Query: "$[?type(@) == 'object' && type(@.x) != 'null'].id", Result: jsonpath.NodeList{2, 3, 4, 5, 6, 7, 8, 9}},
+		{Query: "$[?is_integer(@.x)].id", Result: jsonpath.NodeList{4, 5}},
+		{Query: "$[?!is_integer(@.x)].id", Result: jsonpath.NodeList{1, 2, 3, 6, 7, 8, 9}},
+		{Query: "$[?keys(@.x) == keys($[7].x)].id", Result: jsonpath.NodeList{8}},
+		{Query: "$[?has(@, 'x')].id", Result: jsonpath.NodeList{1, 2, 3, 4, 5, 6, 7, 8}},
+		{Query: "$[?has(@.x, 'a')].id", Result: jsonpath.NodeList{8}},
+		{Query: "$[?has(@.x, 1)].id"},
+		{Query: "$[?is_integer(@.x) == true]", Invalid: true},
+		{Query: "$[?type(@.x)]", Invalid: true},
+	}.Run(t, typefn.Functions(), example)
+}
+
+func TestFunctions_keys(t *testing.T) {
+	example := []any{map[string]any{"b": 1, "a": 2}, map[string]any{"a": 1}, []any{1, 2}}
+	exttest.Cases{
+		{Query: "$[?length(keys(@)) == 2]", Result: jsonpath.NodeList{example[0]}},
+	}.Run(t, typefn.Functions(), example)
+}
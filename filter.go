@@ -4,111 +4,90 @@ import (
 	"fmt"
 	"github.com/0x51-dev/jsonpath/cmp"
 	"github.com/0x51-dev/jsonpath/internal/ir"
-	"sort"
+	"github.com/0x51-dev/jsonpath/internal/node"
 )
 
-func (ctx *context) applyFilterSelector(selector *ir.FilterSelector, node any, recursive bool) NodeList {
-	var nodeList NodeList
-	switch node := node.(type) {
-	case []any:
-		for _, value := range node {
-			if err := ctx.checkLogicalExpr(selector.LogicalExpr, value); err == nil {
-				nodeList = append(nodeList, value)
-			}
+// applyFilterSelector returns the children of the given node for which the logical expression is true.
+func (ctx *context) applyFilterSelector(
+	selector *ir.FilterSelector,
+	location *location,
+	value node.Node,
+) []located {
+	var nodeList []located
+	forEachChild(value, func(c child) {
+		// The location of a child is only built once the child is selected.
+		if err := ctx.checkLogicalExpr(selector.LogicalExpr, c.value); err == nil {
+			nodeList = append(nodeList, located{node: c.value, location: c.locate(location)})
 		}
-
-		if recursive {
-			for _, value := range node {
-				nodeList = append(
-					nodeList,
-					ctx.applyFilterSelector(
-						selector,
-						value,
-						recursive,
-					)...,
-				)
-			}
-		}
-	case map[string]any:
-		var keys []string
-		for key := range node {
-			keys = append(keys, key)
-		}
-		sort.Strings(keys)
-
-		for _, k := range keys {
-			value := node[k]
-			if err := ctx.checkLogicalExpr(selector.LogicalExpr, value); err == nil {
-				nodeList = append(nodeList, value)
-			}
-
-			if recursive {
-				nodeList = append(
-					nodeList,
-					ctx.applyFilterSelector(
-						selector,
-						value,
-						recursive,
-					)...,
-				)
-			}
-		}
-	}
+	})
 	return nodeList
 }
 
-func (ctx *context) checkBasicExpr(expr ir.BasicExpr, node any) error {
+func (ctx *context) checkBasicExpr(expr ir.BasicExpr, current any) error {
 	switch expr := expr.(type) {
 	case *ir.ComparisonExpr:
-		left, err := ctx.value(expr.Left, node)
+		left, err := ctx.value(expr.Left, current)
 		if err != nil {
 			return err
 		}
-		right, err := ctx.value(expr.Right, node)
+		right, err := ctx.value(expr.Right, current)
 		if err != nil {
 			return err
 		}
 		return cmp.Compare(left, right, expr.Op)
 	case *ir.ParenExpr:
-		return ctx.checkLogicalExpr(expr.LogicalExpr, node)
-	case *ir.TestExpr:
+		err := ctx.checkLogicalExpr(expr.LogicalExpr, current)
 		if expr.Negation {
-			panic("not implemented: Negation")
+			return negate(err, expr)
 		}
-		switch expr := expr.TestExpr.(type) {
-		case *ir.RelQuery:
-			v := newContext(node).applyPath(
-				&ir.JSONPathQuery{Segments: expr.Segments},
-			)
-			if v == nil {
-				return fmt.Errorf("no matching expression")
-			}
-			return nil
-		case *ir.JSONPathQuery:
-			panic("not implemented: JSONPathQuery")
-		case *ir.FunctionExpr:
-			return ctx.checkFunctionExpr(expr, node)
-		default:
-			return fmt.Errorf("unsupported test expression type: %T", expr)
+		return err
+	case *ir.TestExpr:
+		err := ctx.checkTestExpr(expr.TestExpr, current)
+		if expr.Negation {
+			return negate(err, expr)
 		}
+		return err
 	default:
 		return fmt.Errorf("unsupported basic expression type: %T", expr)
 	}
 }
 
-func (ctx *context) checkLogicalAndExpr(expr *ir.LogicalAndExpr, node any) error {
+func (ctx *context) checkTestExpr(expr ir.TestExpression, current any) error {
+	switch expr := expr.(type) {
+	case *ir.RelQuery:
+		if v := ctx.applyRelQuery(expr, current); v == nil {
+			return fmt.Errorf("no matching expression")
+		}
+		return nil
+	case *ir.JSONPathQuery:
+		// The existence of an absolute query does not depend on the current node.
+		if v := ctx.applyPath(expr, nil); v == nil {
+			return fmt.Errorf("no matching expression")
+		}
+		return nil
+	case *ir.FunctionExpr:
+		if !ctx.logical(expr, current) {
+			return fmt.Errorf("function %s is false", expr)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported test expression type: %T", expr)
+	}
+}
+
+func (ctx *context) checkLogicalAndExpr(expr *ir.LogicalAndExpr, current any) error {
 	for _, e := range expr.Expressions {
-		if err := ctx.checkBasicExpr(e, node); err != nil {
+		if err := ctx.checkBasicExpr(e, current); err != nil {
 			return err
 		}
 	}
 	return nil // All expressions are true.
 }
 
-func (ctx *context) checkLogicalExpr(expr *ir.LogicalExpr, node any) error {
+func (ctx *context) checkLogicalExpr(expr *ir.LogicalExpr, current any) error {
 	var hit bool
 	for _, e := range expr.Expressions {
-		if err := ctx.checkLogicalAndExpr(e, node); err != nil {
+		if err := ctx.checkLogicalAndExpr(e, current); err != nil {
 			continue
 		}
 		hit = true
@@ -119,37 +98,129 @@ func (ctx *context) checkLogicalExpr(expr *ir.LogicalExpr, node any) error {
 	return nil
 }
 
-func (ctx *context) value(comp ir.Comparable, node any) (any, error) {
+// value returns the value of the comparable, in its JSON representation.
+func (ctx *context) value(comp ir.Comparable, current any) (any, error) {
 	switch comp := comp.(type) {
 	case *ir.AbsSingularQuery:
-		return comp.Value(ctx.root)
+		v, err := ctx.singularValue(comp.Segments, ctx.root)
+		return node.JSON(v), err
 	case *ir.RelSingularQuery:
-		return comp.Value(node)
+		v, err := ctx.singularValue(comp.Segments, current)
+		return node.JSON(v), err
 	case *ir.FunctionExpr:
-		switch comp.Name {
-		case "value":
-			switch arg := comp.Arguments[0].(type) {
-			case *ir.JSONPathQuery:
-				nodeList := ctx.applyPath(arg)
-				if len(nodeList) != 1 {
-					return nil, nil
-				}
-				return nodeList[0], nil
-			case *ir.RelQuery:
-				nodeList := newContext(node).applyPath(&ir.JSONPathQuery{
-					Segments: arg.Segments,
-				})
-				if len(nodeList) != 1 {
-					return nil, nil
-				}
-				return nodeList[0], nil
-			default:
-				panic(fmt.Sprintf("unsupported value arg %T", arg))
-			}
-		default:
-			panic(fmt.Sprintf("unsupported function: %s", comp.Name))
-		}
+		return node.JSON(ctx.call(comp, current)), nil
 	default:
 		return comp.Value(nil)
 	}
 }
+
+// singularValue returns the value selected by the segments of a singular query, or cmp.Nothing if there is none.
+func (ctx *context) singularValue(segments []ir.SingularQuerySegment, current any) (any, error) {
+	for _, segment := range segments {
+		n, ok := ctx.node(current)
+		if !ok {
+			return cmp.Nothing{}, nil
+		}
+		v, err := segment.Value(n)
+		if err != nil {
+			return nil, err
+		}
+		current = v
+	}
+	return current, nil
+}
+
+// argumentLogical returns the logical value of the given function argument. Node lists are true if they are not
+// empty.
+func (ctx *context) argumentLogical(arg ir.FunctionArgument, current any) bool {
+	switch arg := arg.(type) {
+	case *ir.LogicalExpr:
+		return ctx.checkLogicalExpr(arg, current) == nil
+	case *ir.JSONPathQuery, *ir.RelQuery:
+		return len(ctx.argumentNodes(arg, current)) != 0
+	case *ir.FunctionExpr:
+		return ctx.logical(arg, current)
+	default:
+		panic(fmt.Sprintf("unsupported logical argument type: %T", arg))
+	}
+}
+
+// argumentNodes returns the nodes selected by the given function argument.
+func (ctx *context) argumentNodes(arg ir.FunctionArgument, current any) NodeList {
+	switch arg := arg.(type) {
+	case *ir.JSONPathQuery:
+		return nodes(ctx.applyPath(arg, nil))
+	case *ir.RelQuery:
+		return nodes(ctx.applyRelQuery(arg, current))
+	case *ir.FunctionExpr:
+		nodeList, _ := ctx.call(arg, current).(NodeList)
+		return nodeList
+	default:
+		panic(fmt.Sprintf("unsupported nodes argument type: %T", arg))
+	}
+}
+
+// argumentValue returns the value of the given function argument, in its JSON representation. Queries must select
+// exactly one node to produce a value, otherwise the result is cmp.Nothing.
+func (ctx *context) argumentValue(arg ir.FunctionArgument, current any) any {
+	switch arg := arg.(type) {
+	case *ir.JSONPathQuery, *ir.RelQuery:
+		nodeList := ctx.argumentNodes(arg, current)
+		if len(nodeList) != 1 {
+			return cmp.Nothing{}
+		}
+		return node.JSON(nodeList[0])
+	case *ir.FunctionExpr:
+		return node.JSON(ctx.call(arg, current))
+	case ir.Literal:
+		v, err := arg.Value(nil)
+		if err != nil {
+			return cmp.Nothing{}
+		}
+		return v
+	default:
+		panic(fmt.Sprintf("unsupported value argument type: %T", arg))
+	}
+}
+
+// call evaluates the function expression. The arguments are converted to the declared parameter types of the
+// function, which the type check of the query guarantees to be possible.
+func (ctx *context) call(expr *ir.FunctionExpr, current any) any {
+	f, ok := ctx.functions[expr.Name]
+	if !ok {
+		panic(fmt.Sprintf("unsupported function: %s", expr.Name))
+	}
+	args := make([]any, len(expr.Arguments))
+	for i, arg := range expr.Arguments {
+		switch f.Parameters[i] {
+		case ValueType:
+			args[i] = ctx.argumentValue(arg, current)
+		case LogicalType:
+			args[i] = ctx.argumentLogical(arg, current)
+		case NodesType:
+			args[i] = ctx.argumentNodes(arg, current)
+		}
+	}
+	return f.Call(args)
+}
+
+// logical returns the result of the function expression as a logical value. A result of NodesType is true if it is
+// not empty.
+func (ctx *context) logical(expr *ir.FunctionExpr, current any) bool {
+	switch result := ctx.call(expr, current).(type) {
+	case bool:
+		return result
+	case NodeList:
+		return len(result) != 0
+	default:
+		return false
+	}
+}
+
+// negate inverts the result of the given expression, where a nil error represents true.
+func negate(err error, expr ir.BasicExpr) error {
+	if err != nil {
+		return nil
+	}
+	return fmt.Errorf("negated expression: %s", expr)
+}
@@ -122,3 +122,133 @@ func TestPath_Apply_filterSelector(t *testing.T) {
 		},
 	}.Run(t, example)
 }
+
+func TestPath_Apply_filterSelector_negation(t *testing.T) {
+	example := []any{
+		map[string]any{"id": 1, "deleted": true, "name": "a"},
+		map[string]any{"id": 2, "name": "b"},
+		map[string]any{"id": 3, "name": 3},
+	}
+	testCases{
+		{
+			comment: "Negated existence test",
+			query:   "$[?!@.deleted].id",
+			result:  []any{2, 3},
+		},
+		{
+			comment: "Negated function expression",
+			query:   "$[?!match(@.name, 'a')].id",
+			result:  []any{2, 3},
+		},
+		{
+			comment: "Negated parenthesized expression",
+			query:   "$[?!(@.id == 1 || @.id == 3)].id",
+			result:  []any{2},
+		},
+		{
+			comment: "Double negation",
+			query:   "$[?!(!@.deleted)].id",
+			result:  []any{1},
+		},
+	}.Run(t, example)
+}
+
+func TestPath_Apply_filterSelector_absoluteQuery(t *testing.T) {
+	example := map[string]any{
+		"config": map[string]any{"enabled": true, "minLevel": 2, "names": []any{"b", "c"}},
+		"items": []any{
+			map[string]any{"name": "a", "level": 1},
+			map[string]any{"name": "b", "level": 2, "tags": []any{"b"}},
+			map[string]any{"name": "c", "level": 3, "tags": []any{"a", "c"}},
+		},
+	}
+	testCases{
+		{
+			comment: "Existence of an absolute query",
+			query:   "$.items[?$.config.enabled].name",
+			result:  []any{"a", "b", "c"},
+		},
+		{
+			comment: "Negated existence of an absolute query",
+			query:   "$.items[?!$.config.disabled].name",
+			result:  []any{"a", "b", "c"},
+		},
+		{
+			comment: "Absolute query that selects nothing",
+			query:   "$.items[?$.config.disabled].name",
+			result:  nil,
+		},
+		{
+			comment: "Comparison with an absolute singular query",
+			query:   "$.items[?@.level >= $.config.minLevel].name",
+			result:  []any{"b", "c"},
+		},
+		{
+			comment: "Root identifier in a nested filter",
+			query:   "$.items[?@.tags[?@ == $.config.names[0]]].name",
+			result:  []any{"b"},
+		},
+		{
+			comment: "Functions over absolute queries",
+			query:   "$.items[?count($.config.names[*]) == @.level && value($..names[0]) == @.name].name",
+			result:  []any{"b"},
+		},
+		{
+			comment: "Value of an absolute non-singular query",
+			query:   "$.items[?value($.items[?@.level == 3].name) == @.name].level",
+			result:  []any{3},
+		},
+	}.Run(t, example)
+}
+
+// https://www.rfc-editor.org/rfc/rfc9535.html#name-comparisons
+func TestPath_Apply_filterSelector_nothing(t *testing.T) {
+	example := []any{
+		map[string]any{"id": 1, "a": nil, "f": true},
+		map[string]any{"id": 2},
+		map[string]any{"id": 3, "a": 1, "b": 1, "f": false},
+		map[string]any{"id": 4, "a": []any{}, "b": 2},
+	}
+	testCases{
+		{
+			comment: "Null does not match an absent member",
+			query:   "$[?@.a == null].id",
+			result:  []any{1},
+		},
+		{
+			comment: "Absent members are equal",
+			query:   "$[?@.a != @.b].id",
+			result:  []any{1, 4},
+		},
+		{
+			comment: "Absent members are not ordered",
+			query:   "$[?@.b < @.c || @.b > @.c].id",
+			result:  nil,
+		},
+		{
+			comment: "Index into non-array",
+			query:   "$[?@.a[0] != 1].id",
+			result:  []any{1, 2, 3, 4},
+		},
+		{
+			comment: "Negative index",
+			query:   "$[?@[-1] == 1].id",
+			result:  nil,
+		},
+		{
+			comment: "Nothing from functions",
+			query:   "$[?length(@.a) == value(@.c)].id",
+			result:  []any{1, 2, 3},
+		},
+		{
+			comment: "Boolean literal",
+			query:   "$[?@.f == true].id",
+			result:  []any{1},
+		},
+		{
+			comment: "Boolean literal, absent member",
+			query:   "$[?@.f != false].id",
+			result:  []any{1, 2, 4},
+		},
+	}.Run(t, example)
+}
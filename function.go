@@ -2,51 +2,325 @@ package jsonpath
 
 import (
 	"fmt"
+	"github.com/0x51-dev/jsonpath/cmp"
 	"github.com/0x51-dev/jsonpath/internal/ir"
+	"github.com/0x51-dev/jsonpath/internal/iregexp"
+	"github.com/0x51-dev/jsonpath/internal/node"
 	"regexp"
+	"slices"
+	"sort"
+	"sync"
+	"unicode/utf8"
 )
 
-func (ctx *context) checkFunctionExpr(expr *ir.FunctionExpr, node any) error {
-	switch name := expr.Name; name {
-	case "match", "search":
-		if len(expr.Arguments) != 2 {
-			return fmt.Errorf("invalid number of arguments for match: %d", len(expr.Arguments))
-		}
-		s, ok := expr.Arguments[1].(*ir.String)
-		if !ok {
-			return fmt.Errorf("unsupported argument type for match: %T", expr.Arguments[1])
-		}
-		v, err := ctx.value(s, node)
-		if err != nil {
-			return err
-		}
-		r, err := regexp.Compile(v.(string))
-		if err != nil {
-			return err
-		}
-		switch arg := expr.Arguments[0].(type) {
-		case *ir.RelQuery:
-			v := newContext(node).applyPath(
-				&ir.JSONPathQuery{Segments: arg.Segments},
-			)
-			if v == nil || len(v) != 1 {
-				return fmt.Errorf("no matching expression")
-			}
-			s, ok := v[0].(string)
-			if !ok {
-				return fmt.Errorf("unsupported argument type for match: %T", v)
-			}
-			if name == "match" && r.FindString(s) != s {
-				return fmt.Errorf("no matching expression")
-			}
-			if name == "search" && !r.MatchString(s) {
-				return fmt.Errorf("no matching expression")
-			}
-			return nil
-		default:
-			panic("not implemented: match argument type")
+// Type is the declared type of a function parameter or result, as defined in RFC 9535 §2.4.1.
+type Type int
+
+const (
+	// ValueType is the type of any JSON value, and of cmp.Nothing.
+	ValueType = Type(ir.ValueType)
+	// LogicalType is the type of the logical values LogicalTrue and LogicalFalse.
+	LogicalType = Type(ir.LogicalType)
+	// NodesType is the type of node lists.
+	NodesType = Type(ir.NodesType)
+)
+
+func (t Type) String() string {
+	return ir.Type(t).String()
+}
+
+// Function is a function extension, as defined in RFC 9535 §2.4.
+type Function struct {
+	// Name is the name of the function, a lower case letter followed by lower case letters, digits or underscores.
+	Name string
+	// Parameters are the declared types of the parameters.
+	Parameters []Type
+	// Result is the declared type of the result. A result of NodesType can be used as a test expression or as an
+	// argument of a function, but it can not be compared.
+	Result Type
+	// Call evaluates the function. The arguments and the result are represented according to their declared type:
+	//   - ValueType: a JSON value, or cmp.Nothing if there is no value. Arguments are always in their JSON
+	//     representation, see JSONValue.
+	//   - LogicalType: a bool.
+	//   - NodesType: a NodeList. The nodes are values of the queried document, see NodeOf.
+	//
+	// If an argument is not of the expected kind, e.g. not a string, a function of ValueType should return cmp.Nothing,
+	// so that comparisons with its result are false, and a function of LogicalType should return false.
+	Call func(args []any) any
+	// Validate optionally checks the arguments when a query is created, e.g. to reject an invalid pattern. Only
+	// literal arguments are known at that time, all other arguments are cmp.Nothing.
+	Validate func(args []any) error
+}
+
+// validate returns an error if the function can not be registered.
+func (f Function) validate() error {
+	if !functionName.MatchString(f.Name) {
+		return fmt.Errorf("invalid function name: %q", f.Name)
+	}
+	for _, t := range append([]Type{f.Result}, f.Parameters...) {
+		if t != ValueType && t != LogicalType && t != NodesType {
+			return fmt.Errorf("invalid type for function %q: %s", f.Name, t)
 		}
+	}
+	if f.Call == nil {
+		return fmt.Errorf("function %q has no implementation", f.Name)
+	}
+	return nil
+}
+
+// clone returns a copy of the function that does not share its parameters with f, so that changes to the parameters
+// by the caller do not change the signature of a registered function or of a query.
+func (f Function) clone() *Function {
+	f.Parameters = slices.Clone(f.Parameters)
+	return &f
+}
+
+// functionName is the function-name rule of RFC 9535 §2.4.
+var functionName = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+var registry = struct {
+	sync.RWMutex
+	functions map[string]*Function
+}{
+	functions: make(map[string]*Function),
+}
+
+// Register adds the function extensions to the registry, so they can be used by all queries that are created
+// afterwards, e.g. Register(numfn.Functions()...). Register panics if a function is invalid, or if a function with the
+// same name is already registered. In that case, none of the functions are registered.
+func Register(functions ...Function) {
+	for _, f := range functions {
+		if err := f.validate(); err != nil {
+			panic(err)
+		}
+	}
+	registry.Lock()
+	defer registry.Unlock()
+	// The table is copied, since queries keep a reference to the table that was current when they were created.
+	table := make(map[string]*Function, len(registry.functions)+len(functions))
+	for name, f := range registry.functions {
+		table[name] = f
+	}
+	for _, f := range functions {
+		if _, ok := table[f.Name]; ok {
+			panic(fmt.Sprintf("function %q is already registered", f.Name))
+		}
+		table[f.Name] = f.clone()
+	}
+	registry.functions = table
+}
+
+// RegisteredFunctions returns all registered function extensions, sorted by name. This includes the standard
+// functions.
+func RegisteredFunctions() []Function {
+	functions := registeredFunctions()
+	names := make([]string, 0, len(functions))
+	for name := range functions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	list := make([]Function, len(names))
+	for i, name := range names {
+		list[i] = *functions[name].clone()
+	}
+	return list
+}
+
+// StandardFunctions returns the function extensions defined in RFC 9535 §2.4: count, length, match, search and value.
+func StandardFunctions() []Function {
+	functions := registeredFunctions()
+	list := make([]Function, len(standardFunctionNames))
+	for i, name := range standardFunctionNames {
+		list[i] = *functions[name].clone()
+	}
+	return list
+}
+
+// newFunctionTable returns a table of the given functions. It returns an error if a function is invalid, or if
+// several functions have the same name.
+func newFunctionTable(list []Function) (map[string]*Function, error) {
+	functions := make(map[string]*Function, len(list))
+	for _, f := range list {
+		if err := f.validate(); err != nil {
+			return nil, err
+		}
+		if _, ok := functions[f.Name]; ok {
+			return nil, fmt.Errorf("duplicate function %q", f.Name)
+		}
+		functions[f.Name] = f.clone()
+	}
+	return functions, nil
+}
+
+// registeredFunctions returns the current table of registered functions, which must not be modified.
+func registeredFunctions() map[string]*Function {
+	registry.RLock()
+	defer registry.RUnlock()
+	return registry.functions
+}
+
+// signatures returns the signatures of the functions, used to check whether queries are well-typed.
+func signatures(functions map[string]*Function) map[string]ir.FunctionType {
+	types := make(map[string]ir.FunctionType, len(functions))
+	for name, f := range functions {
+		parameters := make([]ir.Type, len(f.Parameters))
+		for i, p := range f.Parameters {
+			parameters[i] = ir.Type(p)
+		}
+		types[name] = ir.FunctionType{
+			Parameters: parameters,
+			Result:     ir.Type(f.Result),
+			Validate:   f.Validate,
+		}
+	}
+	return types
+}
+
+// standardFunctionNames are the names of the function extensions defined in RFC 9535 §2.4.
+var standardFunctionNames []string
+
+func init() {
+	functions := standardFunctions()
+	for _, f := range functions {
+		standardFunctionNames = append(standardFunctionNames, f.Name)
+	}
+	Register(functions...)
+}
+
+// standardFunctions returns the function extensions defined in RFC 9535 §2.4.
+func standardFunctions() []Function {
+	return []Function{
+		{
+			Name:       "count",
+			Parameters: []Type{NodesType},
+			Result:     ValueType,
+			Call:       count,
+		},
+		{
+			Name:       "length",
+			Parameters: []Type{ValueType},
+			Result:     ValueType,
+			Call:       length,
+		},
+		{
+			Name:       "match",
+			Parameters: []Type{ValueType, ValueType},
+			Result:     LogicalType,
+			Call:       newRegexpCache(iregexp.CompileMatch).matchString,
+			Validate:   validatePattern(iregexp.CompileMatch),
+		},
+		{
+			Name:       "search",
+			Parameters: []Type{ValueType, ValueType},
+			Result:     LogicalType,
+			Call:       newRegexpCache(iregexp.CompileSearch).matchString,
+			Validate:   validatePattern(iregexp.CompileSearch),
+		},
+		{
+			Name:       "value",
+			Parameters: []Type{NodesType},
+			Result:     ValueType,
+			Call:       value,
+		},
+	}
+}
+
+// count returns the number of nodes selected by its argument.
+func count(args []any) any {
+	nodeList, _ := args[0].(NodeList)
+	return len(nodeList)
+}
+
+// length returns the length of its argument: the number of Unicode scalar values of a string, the number of elements
+// of an array or the number of members of an object. Any other value results in cmp.Nothing.
+func length(args []any) any {
+	n, ok := node.Of(args[0])
+	if !ok {
+		return cmp.Nothing{}
+	}
+	switch n.Kind() {
+	case node.String:
+		s, _ := n.Value().(string)
+		return utf8.RuneCountInString(s)
+	case node.Array, node.Object:
+		return n.Len()
 	default:
-		panic("not implemented: function name")
+		return cmp.Nothing{}
+	}
+}
+
+// validatePattern returns a validation that checks that a literal pattern of match or search compiles, with the
+// same function that compiles the pattern when the query is applied. Translating the pattern is not enough, since a
+// valid I-Regexp can still exceed the limits of the regexp package, e.g. a{2000}.
+func validatePattern(compile func(pattern string) (*regexp.Regexp, error)) func(args []any) error {
+	return func(args []any) error {
+		if pattern, ok := args[1].(string); ok {
+			if _, err := compile(pattern); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// value returns the value of the single node selected by its argument, or cmp.Nothing if it does not select exactly
+// one node.
+func value(args []any) any {
+	nodeList, _ := args[0].(NodeList)
+	if len(nodeList) != 1 {
+		return cmp.Nothing{}
+	}
+	return nodeList[0]
+}
+
+// regexpCache caches compiled I-Regexp patterns, since a pattern is usually evaluated against many nodes. The cache
+// is cleared when it is full, so patterns taken from the queried documents can not grow it indefinitely.
+type regexpCache struct {
+	sync.Mutex
+	compile func(pattern string) (*regexp.Regexp, error)
+	regexps map[string]*regexp.Regexp
+}
+
+const regexpCacheSize = 256
+
+func newRegexpCache(compile func(pattern string) (*regexp.Regexp, error)) *regexpCache {
+	return &regexpCache{
+		compile: compile,
+		regexps: make(map[string]*regexp.Regexp),
+	}
+}
+
+// matchString reports whether the string matches the pattern. Both arguments must be strings, and the pattern must be
+// a valid I-Regexp, the result is false otherwise.
+func (c *regexpCache) matchString(args []any) any {
+	s, ok := args[0].(string)
+	if !ok {
+		return false
+	}
+	pattern, ok := args[1].(string)
+	if !ok {
+		return false
+	}
+	r, err := c.regexp(pattern)
+	if err != nil {
+		return false
+	}
+	return r.MatchString(s)
+}
+
+func (c *regexpCache) regexp(pattern string) (*regexp.Regexp, error) {
+	c.Lock()
+	defer c.Unlock()
+	if r, ok := c.regexps[pattern]; ok {
+		return r, nil
+	}
+	r, err := c.compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(c.regexps) == regexpCacheSize {
+		clear(c.regexps)
 	}
+	c.regexps[pattern] = r
+	return r, nil
 }
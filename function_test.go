@@ -2,6 +2,9 @@ package jsonpath_test
 
 import (
 	"github.com/0x51-dev/jsonpath"
+	"github.com/0x51-dev/jsonpath/cmp"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -25,6 +28,14 @@ func TestFunctions(t *testing.T) {
 		{
 			query: "$[?count(1) == 1]",
 		},
+		{
+			query:     "$[?count(@..author) == 1]",
+			wellTyped: true,
+		},
+		{
+			query:     "$[?count(@.items[?@.a]) == 1]",
+			wellTyped: true,
+		},
 		{
 			query:     "$[?match(@.timezone, 'Europe/.*')]",
 			wellTyped: true,
@@ -32,6 +43,18 @@ func TestFunctions(t *testing.T) {
 		{
 			query: "$[?match(@.timezone, 'Europe/.*') == true]",
 		},
+		{
+			query: `$[?match(@.timezone, '\\d+')]`,
+		},
+		{
+			query: "$[?search(@.timezone, 'Europe/.*?')]",
+		},
+		{
+			query: "$[?match(@.timezone, 'a{2000}')]",
+		},
+		{
+			query: "$[?search(@.timezone, 'a{2000}')]",
+		},
 		{
 			query:     "$[?value(@..color) == \"red\"]",
 			wellTyped: true,
@@ -39,6 +62,55 @@ func TestFunctions(t *testing.T) {
 		{
 			query: "$[?value(@..color)]",
 		},
+		{
+			query:     "$[?count(@[0, 1]) == 1]",
+			wellTyped: true,
+		},
+		{
+			query: "$[?length(@[0, 1]) == 1]",
+		},
+		{
+			query: "$[?length(@..a) == 1]",
+		},
+		{
+			query:     "$[?length(@['a'][0]) == 1]",
+			wellTyped: true,
+		},
+		{
+			query:     "$[?length($.a) == length(value(@[*]))]",
+			wellTyped: true,
+		},
+		{
+			query: "$[?1 == length(@.*)]",
+		},
+		{
+			query: "$[?count(@.*)]",
+		},
+		{
+			query: "$[?@.a == match(@.b, 'a')]",
+		},
+		{
+			query: "$[?match(@.a)]",
+		},
+		{
+			query: "$[?match(@.a, 'a', 'b')]",
+		},
+		{
+			query: "$[?match(@.*, 'a')]",
+		},
+		{
+			query: "$[?unknown(@.a)]",
+		},
+		{
+			query: "$[?@[?count(1) == 1]]",
+		},
+		{
+			query: "$..[?@..[?length(@.*) == 1]]",
+		},
+		{
+			query:     "$[?!match(@.a, 'a') && (search(@.b, 'b') || count($..c) > 1)]",
+			wellTyped: true,
+		},
 	} {
 		t.Run(test.query, func(t *testing.T) {
 			if _, err := jsonpath.New(test.query); (err == nil) != test.wellTyped {
@@ -47,3 +119,381 @@ func TestFunctions(t *testing.T) {
 		})
 	}
 }
+
+// https://www.rfc-editor.org/rfc/rfc9535.html#name-length-function-extension
+func TestPath_Apply_length(t *testing.T) {
+	example := []any{
+		map[string]any{"tags": []any{"a", "b", "c"}},
+		map[string]any{"tags": []any{"a"}},
+		map[string]any{"tags": "abc"},
+		map[string]any{"tags": "☕☕☕☕"},
+		map[string]any{"tags": map[string]any{"a": 1, "b": 2, "c": 3}},
+		map[string]any{"tags": 4},
+	}
+	testCases{
+		{
+			comment: "Array length",
+			query:   "$[?length(@.tags) > 2]",
+			result: []any{
+				map[string]any{"tags": []any{"a", "b", "c"}},
+				map[string]any{"tags": "abc"},
+				map[string]any{"tags": "☕☕☕☕"},
+				map[string]any{"tags": map[string]any{"a": 1, "b": 2, "c": 3}},
+			},
+		},
+		{
+			comment: "Length on the right-hand side",
+			query:   "$[?1 == length(@.tags)]",
+			result: []any{
+				map[string]any{"tags": []any{"a"}},
+			},
+		},
+		{
+			comment: "String length in Unicode scalar values",
+			query:   "$[?length(@.tags) == 4]",
+			result: []any{
+				map[string]any{"tags": "☕☕☕☕"},
+			},
+		},
+		{
+			comment: "Length of a literal",
+			query:   "$[?length('ab') == 2].tags",
+			result:  []any{[]any{"a", "b", "c"}, []any{"a"}, "abc", "☕☕☕☕", map[string]any{"a": 1, "b": 2, "c": 3}, 4},
+		},
+		{
+			comment: "Length of a number",
+			query:   "$[?length(@.tags) >= 0].tags",
+			result:  []any{[]any{"a", "b", "c"}, []any{"a"}, "abc", "☕☕☕☕", map[string]any{"a": 1, "b": 2, "c": 3}},
+		},
+	}.Run(t, example)
+}
+
+// https://www.rfc-editor.org/rfc/rfc9535.html#name-count-function-extension
+func TestPath_Apply_count(t *testing.T) {
+	example := map[string]any{
+		"records": []any{
+			map[string]any{"items": []any{}},
+			map[string]any{"items": []any{1, 2}},
+			map[string]any{"items": []any{map[string]any{"author": "a"}, map[string]any{"author": "b", "x": map[string]any{"author": "c"}}}},
+		},
+	}
+	testCases{
+		{
+			comment: "Empty collection",
+			query:   "$.records[?count(@.items[*]) == 0]",
+			result:  []any{map[string]any{"items": []any{}}},
+		},
+		{
+			comment: "Descendant segment",
+			query:   "$.records[?count(@..author) == 3].items[0]",
+			result:  []any{map[string]any{"author": "a"}},
+		},
+		{
+			comment: "Filter segment",
+			query:   "$.records[?count(@.items[?@ > 1]) == 1].items",
+			result:  []any{[]any{1, 2}},
+		},
+		{
+			comment: "Singular query",
+			query:   "$.records[?count(@.items[0]) == 0 && count(@.missing) == 0].items",
+			result:  []any{[]any{}},
+		},
+		{
+			comment: "Absolute query",
+			query:   "$.records[?count($..author) == 3 && @.items[0] == 1].items",
+			result:  []any{[]any{1, 2}},
+		},
+	}.Run(t, example)
+}
+
+// https://www.rfc-editor.org/rfc/rfc9535.html#name-match-function-extension
+func TestPath_Apply_match(t *testing.T) {
+	example := map[string]any{
+		"rules": map[string]any{"codePattern": "[A-Z]{2}[0-9]+", "invalid": `\d`},
+		"records": []any{
+			map[string]any{"code": "AB12", "alias": "ab"},
+			map[string]any{"code": "AB12x", "alias": "x"},
+			map[string]any{"code": 12},
+			map[string]any{"code": "xAB12"},
+		},
+	}
+	testCases{
+		{
+			comment: "Pattern from the document",
+			query:   "$.records[?match(@.code, $.rules.codePattern)].code",
+			result:  []any{"AB12"},
+		},
+		{
+			comment: "Search with a pattern from the document",
+			query:   "$.records[?search(@.code, $.rules.codePattern)].code",
+			result:  []any{"AB12", "AB12x", "xAB12"},
+		},
+		{
+			comment: "Value function as argument",
+			query:   "$.records[?match(value(@..alias), 'a.')].code",
+			result:  []any{"AB12"},
+		},
+		{
+			comment: "Absolute singular query as argument",
+			query:   "$.records[?match($.rules.codePattern, '.*Z.*')].alias",
+			result:  []any{"ab", "x"},
+		},
+		{
+			comment: "Non-string argument",
+			query:   "$.records[?!match(@.code, '.*')].code",
+			result:  []any{12},
+		},
+		{
+			comment: "Anchors are ordinary characters",
+			query:   `$.records[?search(@.code, '^AB')].code`,
+			result:  nil,
+		},
+		{
+			comment: "Invalid pattern from the document",
+			query:   "$.records[?search(@.code, $.rules.invalid)].code",
+			result:  nil,
+		},
+		{
+			comment: "Non-string pattern",
+			query:   "$.records[?search(@.code, @.missing)].code",
+			result:  nil,
+		},
+	}.Run(t, example)
+	testCases{
+		{
+			comment: "Dot does not match line terminators",
+			query:   "$[?match(@, 'a.c')]",
+			result:  []any{"abc"},
+		},
+	}.Run(t, []any{"a\nc", "abc", "a\rc"})
+}
+
+// The functions are registered once, since tests may run several times.
+// testParameters are the parameters of test_parameters, which the tests modify after registration.
+var testParameters = []jsonpath.Type{jsonpath.ValueType}
+
+func init() {
+	jsonpath.Register(jsonpath.Function{
+		Name:       "test_first",
+		Parameters: []jsonpath.Type{jsonpath.NodesType},
+		Result:     jsonpath.ValueType,
+		Call: func(args []any) any {
+			nodeList := args[0].(jsonpath.NodeList)
+			if len(nodeList) == 0 {
+				return cmp.Nothing{}
+			}
+			return nodeList[0]
+		},
+	})
+	jsonpath.Register(jsonpath.Function{
+		Name:       "test_either",
+		Parameters: []jsonpath.Type{jsonpath.LogicalType, jsonpath.LogicalType},
+		Result:     jsonpath.LogicalType,
+		Call: func(args []any) any {
+			return args[0].(bool) != args[1].(bool)
+		},
+	})
+	jsonpath.Register(jsonpath.Function{
+		Name:       "test_parameters",
+		Parameters: testParameters,
+		Result:     jsonpath.ValueType,
+		Call: func(args []any) any {
+			return args[0]
+		},
+	})
+	jsonpath.Register(jsonpath.Function{
+		Name:       "test_elements",
+		Parameters: []jsonpath.Type{jsonpath.NodesType},
+		Result:     jsonpath.NodesType,
+		Call: func(args []any) any {
+			var nodeList jsonpath.NodeList
+			for _, node := range args[0].(jsonpath.NodeList) {
+				if a, ok := node.([]any); ok {
+					nodeList = append(nodeList, a...)
+				}
+			}
+			return nodeList
+		},
+	})
+}
+
+func TestRegister(t *testing.T) {
+	example := []any{
+		map[string]any{"a": []any{1, 2}},
+		map[string]any{"a": []any{2, 1}, "b": true},
+		map[string]any{"b": true},
+	}
+	testCases{
+		{
+			comment: "ValueType result of NodesType argument",
+			query:   "$[?test_first(@.a[*]) == 2]",
+			result:  []any{map[string]any{"a": []any{2, 1}, "b": true}},
+		},
+		{
+			comment: "LogicalType arguments",
+			query:   "$[?test_either(@.a, @.b)]",
+			result:  []any{map[string]any{"a": []any{1, 2}}, map[string]any{"b": true}},
+		},
+		{
+			comment: "NodesType result as test expression",
+			query:   "$[?test_elements(@[*])]",
+			result:  []any{map[string]any{"a": []any{1, 2}}, map[string]any{"a": []any{2, 1}, "b": true}},
+		},
+		{
+			comment: "NodesType result as NodesType argument",
+			query:   "$[?count(test_elements(@.*)) == 2 && test_first(test_elements(@.a)) == 1]",
+			result:  []any{map[string]any{"a": []any{1, 2}}},
+		},
+		{
+			comment: "NodesType result as LogicalType argument",
+			query:   "$[?test_either(test_elements(@.*), @.b)]",
+			result:  []any{map[string]any{"a": []any{1, 2}}, map[string]any{"b": true}},
+		},
+		{
+			comment: "comparisons and logical operators as LogicalType arguments",
+			query:   "$[?test_either(@.a[0] == 1, @.b && @.a)]",
+			result:  []any{map[string]any{"a": []any{1, 2}}, map[string]any{"a": []any{2, 1}, "b": true}},
+		},
+		{
+			comment: "nested logical expressions as LogicalType arguments",
+			query:   "$[?test_either(@.a[0] == 2 || !@.b, ($[0].a[1] == 2))]",
+			result:  []any{map[string]any{"b": true}},
+		},
+	}.Run(t, example)
+
+	for _, query := range []string{
+		"$[?test_first(@.a)]",
+		"$[?test_first(@.a, @.b) == 1]",
+		"$[?test_either(@.a, 1)]",
+		"$[?test_elements(@.a) == 1]",
+		"$[?1 != test_elements(@.a)]",
+		"$[?length(test_elements(@.a)) == 1]",
+		"$[?test_first(@.a == 1) == 1]",
+		"$[?length(@.a && @.b) == 1]",
+	} {
+		if _, err := jsonpath.New(query); err == nil {
+			t.Errorf("%s: expected the query to be not well-typed", query)
+		}
+	}
+
+	for _, f := range []jsonpath.Function{
+		{Name: "length", Result: jsonpath.ValueType, Call: func([]any) any { return nil }},
+		{Name: "Test", Result: jsonpath.ValueType, Call: func([]any) any { return nil }},
+		{Name: "test_nil", Result: jsonpath.ValueType},
+		{Name: "test_type", Call: func([]any) any { return nil }},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: expected a panic", f.Name)
+				}
+			}()
+			jsonpath.Register(f)
+		}()
+	}
+
+	// A batch with a duplicate function is not registered at all.
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected a panic")
+			}
+		}()
+		valid := jsonpath.Function{Name: "test_batch", Result: jsonpath.ValueType, Call: func([]any) any { return nil }}
+		jsonpath.Register(valid, valid)
+	}()
+	if _, err := jsonpath.New("$[?test_batch() == 1]"); err == nil {
+		t.Error("expected test_batch to be unregistered")
+	}
+}
+
+// The signature of a function can not be changed through the parameters given to Register or WithFunctions, or
+// returned by RegisteredFunctions.
+func TestRegister_parameters(t *testing.T) {
+	testParameters[0] = jsonpath.NodesType
+	for _, f := range jsonpath.RegisteredFunctions() {
+		if f.Name == "test_parameters" {
+			f.Parameters[0] = jsonpath.NodesType
+		}
+	}
+	parameters := []jsonpath.Type{jsonpath.ValueType}
+	identity := jsonpath.Function{
+		Name:       "test_parameters",
+		Parameters: parameters,
+		Result:     jsonpath.ValueType,
+		Call:       func(args []any) any { return args[0] },
+	}
+	restricted, err := jsonpath.New("$[?test_parameters(@.a) == 1]", jsonpath.WithFunctions(identity))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parameters[0] = jsonpath.NodesType
+
+	if _, err := jsonpath.New("$[?test_parameters(@.a) == 1]"); err != nil {
+		t.Errorf("expected a ValueType parameter: %v", err)
+	}
+	if _, err := jsonpath.New("$[?test_parameters(@.*) == 1]"); err == nil {
+		t.Error("expected a ValueType parameter, not NodesType")
+	}
+	if r := restricted.Apply([]any{map[string]any{"a": 1}}); len(r) != 1 {
+		t.Errorf("expected one node, got %v", r)
+	}
+}
+
+func TestNew_withFunctions(t *testing.T) {
+	var restricted []jsonpath.Function
+	for _, f := range jsonpath.StandardFunctions() {
+		if f.Name != "match" && f.Name != "search" {
+			restricted = append(restricted, f)
+		}
+	}
+	twice := jsonpath.Function{
+		Name:       "twice",
+		Parameters: []jsonpath.Type{jsonpath.ValueType},
+		Result:     jsonpath.ValueType,
+		Call: func(args []any) any {
+			n, ok := args[0].(float64)
+			if !ok {
+				return cmp.Nothing{}
+			}
+			return 2 * n
+		},
+	}
+	rich := append(jsonpath.StandardFunctions(), twice)
+
+	for _, test := range []struct {
+		query     string
+		functions []jsonpath.Function
+		err       string
+	}{
+		{query: "$[?length(@) == 1]", functions: restricted},
+		{query: "$[?match(@, 'a')]", functions: restricted, err: "available functions are: count, length, value"},
+		{query: "$[?twice(@) == 2]", err: `unknown function "twice"`},
+		{query: "$[?twice(@) == 2 && match(@, 'a')]", functions: rich},
+		{query: "$[?length(@) == 1]", functions: []jsonpath.Function{}, err: "available functions are: "},
+		{query: "$", functions: []jsonpath.Function{twice, twice}, err: `duplicate function "twice"`},
+	} {
+		var opts []jsonpath.Option
+		if test.functions != nil {
+			opts = append(opts, jsonpath.WithFunctions(test.functions...))
+		}
+		_, err := jsonpath.New(test.query, opts...)
+		if test.err == "" {
+			if err != nil {
+				t.Errorf("%s: %v", test.query, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: expected error %q, got %v", test.query, test.err, err)
+		}
+	}
+
+	q, err := jsonpath.New("$[?twice(@) == 4]", jsonpath.WithFunctions(rich...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := q.Apply([]any{1.0, 2.0, "2"}); !reflect.DeepEqual(r, jsonpath.NodeList{2.0}) {
+		t.Errorf("unexpected result: %v", r)
+	}
+}
@@ -0,0 +1,46 @@
+// Package exttest runs the test cases of the function extensions in the ext packages.
+package exttest
+
+import (
+	"github.com/0x51-dev/jsonpath"
+	"reflect"
+	"testing"
+)
+
+// Case is a query together with the nodes it selects.
+type Case struct {
+	Query  string
+	Result jsonpath.NodeList
+	// Invalid is true if the query must be rejected by jsonpath.New, e.g. because it is not well-typed.
+	Invalid bool
+}
+
+// Run creates the query with the given option and applies it to v.
+func (c Case) Run(t *testing.T, option jsonpath.Option, v any) {
+	t.Run(c.Query, func(t *testing.T) {
+		q, err := jsonpath.New(c.Query, option)
+		if c.Invalid {
+			if err == nil {
+				t.Error("expected the query to be invalid")
+			}
+			return
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r := q.Apply(v); !reflect.DeepEqual(r, c.Result) {
+			t.Errorf("expected %v, got %v", c.Result, r)
+		}
+	})
+}
+
+// Cases is a list of test cases of function extensions.
+type Cases []Case
+
+// Run runs every case with the standard functions and the given function extensions.
+func (c Cases) Run(t *testing.T, functions []jsonpath.Function, v any) {
+	option := jsonpath.WithFunctions(append(jsonpath.StandardFunctions(), functions...)...)
+	for _, test := range c {
+		test.Run(t, option, v)
+	}
+}
@@ -25,10 +25,10 @@ var (
 	Unescaped             = op.Or{op.RuneRange{Min: 0x20, Max: 0x21}, op.RuneRange{Min: 0x23, Max: 0x26}, op.RuneRange{Min: 0x28, Max: 0x5B}, op.RuneRange{Min: 0x5D, Max: 0xD7FF}, op.RuneRange{Min: 0xE000, Max: 0x10FFFF}}
 	Escapable             = op.Or{rune(0x62), rune(0x66), rune(0x6E), rune(0x72), rune(0x74), '/', '\\', op.And{rune(0x75), Hexchar}}
 	Hexchar               = op.Capture{Name: "Hexchar", Value: op.Or{NonSurrogate, op.And{HighSurrogate, '\\', rune(0x75), LowSurrogate}}}
-	NonSurrogate          = op.Or{op.And{op.Or{DIGIT, 'A', 'B', 'C', 'E', 'F'}, op.Repeat{Min: 3, Max: 3, Value: HEXDIG}}, op.And{'D', op.RuneRange{Min: 0x30, Max: 0x37}, op.Repeat{Min: 2, Max: 2, Value: HEXDIG}}}
-	HighSurrogate         = op.And{'D', op.Or{'8', '9', 'A', 'B'}, op.Repeat{Min: 2, Max: 2, Value: HEXDIG}}
-	LowSurrogate          = op.And{'D', op.Or{'C', 'D', 'E', 'F'}, op.Repeat{Min: 2, Max: 2, Value: HEXDIG}}
-	HEXDIG                = op.Or{DIGIT, 'A', 'B', 'C', 'D', 'E', 'F'}
+	NonSurrogate          = op.Or{op.And{op.Or{DIGIT, 'A', 'B', 'C', 'E', 'F', 'a', 'b', 'c', 'e', 'f'}, op.Repeat{Min: 3, Max: 3, Value: HEXDIG}}, op.And{op.Or{'D', 'd'}, op.RuneRange{Min: 0x30, Max: 0x37}, op.Repeat{Min: 2, Max: 2, Value: HEXDIG}}}
+	HighSurrogate         = op.And{op.Or{'D', 'd'}, op.Or{'8', '9', 'A', 'B', 'a', 'b'}, op.Repeat{Min: 2, Max: 2, Value: HEXDIG}}
+	LowSurrogate          = op.And{op.Or{'D', 'd'}, op.Or{'C', 'D', 'E', 'F', 'c', 'd', 'e', 'f'}, op.Repeat{Min: 2, Max: 2, Value: HEXDIG}}
+	HEXDIG                = op.Or{DIGIT, 'A', 'B', 'C', 'D', 'E', 'F', 'a', 'b', 'c', 'd', 'e', 'f'}
 	WildcardSelector      = op.Capture{Name: "WildcardSelector", Value: '*'}
 	IndexSelector         = op.Capture{Name: "IndexSelector", Value: Int}
 	Int                   = op.Capture{Name: "Int", Value: op.Or{'0', op.And{op.Optional{Value: '-'}, DIGIT1, op.ZeroOrMore{Value: DIGIT}}}}
@@ -61,7 +61,7 @@ var (
 	Number                = op.Capture{Name: "Number", Value: op.And{op.Or{Int, MinZero}, op.Optional{Value: Frac}, op.Optional{Value: Exp}}}
 	MinZero               = op.Capture{Name: "MinZero", Value: "-0"}
 	Frac                  = op.Capture{Name: "Frac", Value: op.And{'.', op.OneOrMore{Value: DIGIT}}}
-	Exp                   = op.Capture{Name: "Exp", Value: op.And{'e', op.Optional{Value: op.Or{'-', '+'}}, op.OneOrMore{Value: DIGIT}}}
+	Exp                   = op.Capture{Name: "Exp", Value: op.And{op.Or{'e', 'E'}, op.Optional{Value: op.Or{'-', '+'}}, op.OneOrMore{Value: DIGIT}}}
 	True                  = op.Capture{Name: "True", Value: op.And{rune(0x74), rune(0x72), rune(0x75), rune(0x65)}}
 	False                 = op.Capture{Name: "False", Value: op.And{rune(0x66), rune(0x61), rune(0x6c), rune(0x73), rune(0x65)}}
 	Null                  = op.Capture{Name: "Null", Value: op.And{rune(0x6e), rune(0x75), rune(0x6c), rune(0x6c)}}
@@ -70,7 +70,7 @@ var (
 	FunctionNameChar      = op.Or{FunctionNameFirst, '_', DIGIT}
 	LCALPHA               = op.RuneRange{Min: 0x61, Max: 0x7A}
 	FunctionExpr          = op.Capture{Name: "FunctionExpr", Value: op.And{FunctionName, '(', S, op.Optional{Value: op.And{FunctionArgument, op.ZeroOrMore{Value: op.And{S, ',', S, FunctionArgument}}}}, S, ')'}}
-	FunctionArgument      = op.Capture{Name: "FunctionArgument", Value: op.Or{Literal, FilterQuery, op.Reference{Name: "LogicalExpr"}, op.Reference{Name: "FunctionExpr"}}}
+	FunctionArgument      = op.Capture{Name: "FunctionArgument", Value: op.Or{op.Reference{Name: "LogicalExpr"}, Literal, FilterQuery, op.Reference{Name: "FunctionExpr"}}}
 	Segment               = op.Capture{Name: "Segment", Value: op.Or{ChildSegment, DescendantSegment}}
 	ChildSegment          = op.Capture{Name: "ChildSegment", Value: op.Or{BracketedSelection, op.And{'.', op.Or{WildcardSelector, MemberNameShorthand}}}}
 	BracketedSelection    = op.Capture{Name: "BracketedSelection", Value: op.And{'[', S, Selector, op.ZeroOrMore{Value: op.And{S, ',', S, Selector}}, S, ']'}}
@@ -20,3 +20,37 @@ type InvalidNodeStructure struct {
 func (e InvalidNodeStructure) Error() string {
 	return fmt.Sprintf("invalid node structure for %q: %v", e.Name, e.Node)
 }
+
+func NewRangeError(selector fmt.Stringer, value string) error {
+	return &RangeError{
+		Selector: selector.String(),
+		Value:    value,
+	}
+}
+
+// RangeError is returned when an integer of an index or slice selector is outside the I-JSON range [MinInt, MaxInt].
+type RangeError struct {
+	Selector string
+	Value    string
+}
+
+func (e RangeError) Error() string {
+	return fmt.Sprintf("integer %s of selector %q is out of range [%d, %d]", e.Value, e.Selector, MinInt, MaxInt)
+}
+
+func NewTypeError(expr fmt.Stringer, format string, args ...any) error {
+	return &TypeError{
+		Expression: expr.String(),
+		Reason:     fmt.Sprintf(format, args...),
+	}
+}
+
+// TypeError is returned when a query is not well-typed.
+type TypeError struct {
+	Expression string
+	Reason     string
+}
+
+func (e TypeError) Error() string {
+	return fmt.Sprintf("%s is not well-typed: %s", e.Expression, e.Reason)
+}
@@ -0,0 +1,180 @@
+package ir
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Style is the notation used to format a query.
+type Style int
+
+const (
+	// Canonical uses bracket notation for all segments and single quotes for all strings, e.g. $['a'][*]. The
+	// exponent of a number literal is written as e without a plus sign, but number literals are otherwise kept as
+	// written, e.g. 1.0 and 1.00 differ.
+	Canonical Style = iota
+	// Shorthand uses dot notation for member names and wildcards wherever it is allowed, e.g. $.a.*.
+	Shorthand
+)
+
+// Format returns the query in the given style. Whitespace is normalized, and parsing the result yields a query that
+// is identical to q.
+func Format(q *JSONPathQuery, style Style) string {
+	if style == Canonical {
+		return q.String()
+	}
+	return formatSegments("$", q.Segments)
+}
+
+func formatArgument(arg FunctionArgument) string {
+	switch arg := arg.(type) {
+	case *JSONPathQuery:
+		return formatSegments("$", arg.Segments)
+	case *RelQuery:
+		return formatSegments("@", arg.Segments)
+	case *LogicalExpr:
+		return formatLogicalExpr(arg)
+	case *FunctionExpr:
+		return formatFunctionExpr(arg)
+	default:
+		return arg.String()
+	}
+}
+
+func formatBasicExpr(expr BasicExpr) string {
+	switch expr := expr.(type) {
+	case *ParenExpr:
+		if expr.Negation {
+			return fmt.Sprintf("!(%s)", formatLogicalExpr(expr.LogicalExpr))
+		}
+		return fmt.Sprintf("(%s)", formatLogicalExpr(expr.LogicalExpr))
+	case *ComparisonExpr:
+		return fmt.Sprintf("%s %s %s", formatComparable(expr.Left), expr.Op, formatComparable(expr.Right))
+	case *TestExpr:
+		var str string
+		switch test := expr.TestExpr.(type) {
+		case *RelQuery:
+			str = formatSegments("@", test.Segments)
+		case *JSONPathQuery:
+			str = formatSegments("$", test.Segments)
+		case *FunctionExpr:
+			str = formatFunctionExpr(test)
+		default:
+			str = test.String()
+		}
+		if expr.Negation {
+			return "!" + str
+		}
+		return str
+	default:
+		return expr.String()
+	}
+}
+
+func formatComparable(comparable Comparable) string {
+	switch comparable := comparable.(type) {
+	case *RelSingularQuery:
+		return formatSingularQuery("@", comparable.Segments)
+	case *AbsSingularQuery:
+		return formatSingularQuery("$", comparable.Segments)
+	case *FunctionExpr:
+		return formatFunctionExpr(comparable)
+	default:
+		return comparable.String()
+	}
+}
+
+func formatFunctionExpr(expr *FunctionExpr) string {
+	var str []string
+	for _, a := range expr.Arguments {
+		str = append(str, formatArgument(a))
+	}
+	return fmt.Sprintf("%s(%s)", expr.Name, strings.Join(str, ", "))
+}
+
+func formatLogicalExpr(expr *LogicalExpr) string {
+	var or []string
+	for _, e := range expr.Expressions {
+		var and []string
+		for _, e := range e.Expressions {
+			and = append(and, formatBasicExpr(e))
+		}
+		or = append(or, strings.Join(and, " && "))
+	}
+	return strings.Join(or, " || ")
+}
+
+// formatSelection returns the selection in dot notation if it consists of a single wildcard, or a single name that
+// is a valid member-name-shorthand. Otherwise, it returns the selection in bracket notation.
+func formatSelection(s *BracketedSelection, dot string) string {
+	if len(s.Selectors) == 1 {
+		switch selector := s.Selectors[0].(type) {
+		case *WildcardSelector:
+			return dot + "*"
+		case *NameSelector:
+			if isMemberNameShorthand(selector.Name) {
+				return dot + selector.Name
+			}
+		}
+	}
+	var str []string
+	for _, selector := range s.Selectors {
+		if f, ok := selector.(*FilterSelector); ok {
+			str = append(str, "?"+formatLogicalExpr(f.LogicalExpr))
+			continue
+		}
+		str = append(str, selector.String())
+	}
+	if dot == "." {
+		// Child segments in bracket notation have no prefix.
+		dot = ""
+	}
+	return dot + "[" + strings.Join(str, ", ") + "]"
+}
+
+func formatSegments(identifier string, segments []Segment) string {
+	str := identifier
+	for _, segment := range segments {
+		switch segment := segment.(type) {
+		case *BracketedSelection:
+			str += formatSelection(segment, ".")
+		case *DescendantSegment:
+			str += formatSelection(segment.Segment, "..")
+		default:
+			str += segment.String()
+		}
+	}
+	return str
+}
+
+func formatSingularQuery(identifier string, segments []SingularQuerySegment) string {
+	str := identifier
+	for _, segment := range segments {
+		if s, ok := segment.(*NameSegment); ok && isMemberNameShorthand(s.Name) {
+			str += "." + s.Name
+			continue
+		}
+		str += segment.String()
+	}
+	return str
+}
+
+// isMemberNameShorthand reports whether the name can be written as a member-name-shorthand, i.e. in dot notation.
+func isMemberNameShorthand(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case 'A' <= r && r <= 'Z', 'a' <= r && r <= 'z', r == '_':
+		case 0x80 <= r && r <= 0xD7FF, 0xE000 <= r && r <= 0x10FFFF:
+		case '0' <= r && r <= '9':
+			if i == 0 {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,68 @@
+package ir
+
+import (
+	"github.com/0x51-dev/jsonpath/internal/grammar"
+	"github.com/0x51-dev/upeg/parser/op"
+	"reflect"
+	"testing"
+)
+
+func parseQuery(t *testing.T, query string) *JSONPathQuery {
+	t.Helper()
+	p, err := grammar.NewParser([]rune(query))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := p.Parse(op.And{grammar.JsonpathQuery, op.EOF{}})
+	if err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+	q, err := ParseJSONPathQuery(n)
+	if err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+	return q
+}
+
+func TestFormat(t *testing.T) {
+	for _, test := range []struct {
+		query     string
+		canonical string
+		shorthand string
+	}{
+		{"$", "$", "$"},
+		{"$.a.*", "$['a'][*]", "$.a.*"},
+		{`$["a"][*]`, "$['a'][*]", "$.a.*"},
+		{"$..a..*", "$..['a']..[*]", "$..a..*"},
+		{"$..['a', 'b']", "$..['a', 'b']", "$..['a', 'b']"},
+		{"$['a b']['1'][''][\"_1\"]", "$['a b']['1']['']['_1']", "$['a b']['1']['']._1"},
+		{"$[ 0 , -1 , 1:-1 , ::-2 ]", "$[0, -1, 1:-1, ::-2]", "$[0, -1, 1:-1, ::-2]"},
+		{"$.☺['\\u263a']", "$['☺']['☺']", "$.☺.☺"},
+		{"$[\"'\\n\"]", `$['\'\n']`, `$['\'\n']`},
+		{
+			"$.a[?@.b==1&&!(@['c']||$.d)]",
+			"$['a'][?@['b'] == 1 && !(@['c'] || $['d'])]",
+			"$.a[?@.b == 1 && !(@.c || $.d)]",
+		},
+		{
+			"$[?length(@.a)>=2 && match(@['b c'], \"x.*\") && count(@..*) < 3]",
+			"$[?length(@['a']) >= 2 && match(@['b c'], 'x.*') && count(@..[*]) < 3]",
+			"$[?length(@.a) >= 2 && match(@['b c'], 'x.*') && count(@..*) < 3]",
+		},
+		{"$[?@[0].a, ?!@.*]", "$[?@[0]['a'], ?!@[*]]", "$[?@[0].a, ?!@.*]"},
+		{"$[?@.a==1E2 || @.a==1e+2]", "$[?@['a'] == 1e2 || @['a'] == 1e2]", "$[?@.a == 1e2 || @.a == 1e2]"},
+		{"$[?@.a==1.5E-1]", "$[?@['a'] == 1.5e-1]", "$[?@.a == 1.5e-1]"},
+	} {
+		q := parseQuery(t, test.query)
+		for style, expected := range map[Style]string{Canonical: test.canonical, Shorthand: test.shorthand} {
+			str := Format(q, style)
+			if str != expected {
+				t.Errorf("%s: expected %s, got %s", test.query, expected, str)
+			}
+			// Parsing the formatted query must result in an identical query.
+			if r := parseQuery(t, str); !reflect.DeepEqual(q, r) {
+				t.Errorf("%s: %s is not identical", test.query, str)
+			}
+		}
+	}
+}
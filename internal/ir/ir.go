@@ -2,16 +2,38 @@ package ir
 
 import (
 	"fmt"
+	"github.com/0x51-dev/jsonpath/cmp"
+	"github.com/0x51-dev/jsonpath/internal/node"
 	"github.com/0x51-dev/upeg/parser"
 	"strconv"
 	"strings"
 )
 
-func parseInt(n *parser.Node) (int, error) {
+const (
+	// MinInt is the smallest integer that can be used in an index or slice selector, as defined by I-JSON (RFC 7493).
+	MinInt int64 = -(1 << 53) + 1
+	// MaxInt is the largest integer that can be used in an index or slice selector, as defined by I-JSON (RFC 7493).
+	MaxInt int64 = 1<<53 - 1
+)
+
+// parseInt parses an integer of the given selector, which must be within the range [MinInt, MaxInt]. The result is an
+// int64, since the range exceeds int on 32-bit platforms.
+func parseInt(n *parser.Node, selector fmt.Stringer) (int64, error) {
 	if n.Name != "Int" {
 		return 0, NewInvalidNodeStructureError("Int", n)
 	}
-	return strconv.Atoi(n.Value())
+	i, err := strconv.ParseInt(n.Value(), 10, 64)
+	if err != nil || i < MinInt || MaxInt < i {
+		return 0, NewRangeError(selector, n.Value())
+	}
+	return i, nil
+}
+
+// rawSelector is the text of a selector as it was written in the query, without whitespace.
+type rawSelector string
+
+func (s rawSelector) String() string {
+	return string(s)
 }
 
 type AbsSingularQuery struct {
@@ -118,6 +140,25 @@ func (s BracketedSelection) childSegment() {}
 
 func (s BracketedSelection) segment() {}
 
+// parseSelection parses the selection of a child or descendant segment. The shorthand notations .name and .* are
+// parsed as the bracketed selections ['name'] and [*], so the result does not depend on the notation of the query.
+func parseSelection(n *parser.Node) (*BracketedSelection, error) {
+	switch n.Name {
+	case "BracketedSelection":
+		return ParseBracketedSelection(n)
+	case "WildcardSelector":
+		return &BracketedSelection{
+			Selectors: []Selector{new(WildcardSelector)},
+		}, nil
+	case "MemberNameShorthand":
+		return &BracketedSelection{
+			Selectors: []Selector{&NameSelector{Name: n.Value()}},
+		}, nil
+	default:
+		return nil, NewInvalidNodeStructureError("BracketedSelection", n)
+	}
+}
+
 type ChildSegment interface {
 	Segment
 
@@ -129,22 +170,7 @@ func ParseChildSegment(n *parser.Node) (ChildSegment, error) {
 	if n.Name != name {
 		return nil, NewInvalidNodeStructureError(name, n)
 	}
-	switch n := n.Children()[0]; n.Name {
-	case "BracketedSelection":
-		s, err := ParseBracketedSelection(n)
-		if err != nil {
-			return nil, err
-		}
-		return s, nil
-	case "WildcardSelector":
-		return new(WildcardSelector), nil
-	case "MemberNameShorthand":
-		return &MemberNameShorthand{
-			Name: n.Value(),
-		}, nil
-	default:
-		return nil, NewInvalidNodeStructureError(name, n)
-	}
+	return parseSelection(n.Children()[0])
 }
 
 type Comparable interface {
@@ -191,17 +217,11 @@ func ParseComparisonExpr(n *parser.Node) (*ComparisonExpr, error) {
 	if err != nil {
 		return nil, err
 	}
-	if typ := typeOfArgument(left); typ != valueType && typ != unknownType {
-		return nil, fmt.Errorf("invalid arg type %s for %s", left, name)
-	}
 	op := cs[1].Value()
 	right, err := ParseComparable(cs[2])
 	if err != nil {
 		return nil, err
 	}
-	if typ := typeOfArgument(left); typ != valueType && typ != unknownType {
-		return nil, fmt.Errorf("invalid arg type %s for %s", left, name)
-	}
 	return &ComparisonExpr{
 		Left:  left,
 		Op:    op,
@@ -216,7 +236,7 @@ func (s ComparisonExpr) String() string {
 func (s ComparisonExpr) basicExpr() {}
 
 type DescendantSegment struct {
-	Segment Segment
+	Segment *BracketedSelection
 }
 
 func ParseDescendantSegment(n *parser.Node) (*DescendantSegment, error) {
@@ -224,35 +244,16 @@ func ParseDescendantSegment(n *parser.Node) (*DescendantSegment, error) {
 	if n.Name != name {
 		return nil, NewInvalidNodeStructureError(name, n)
 	}
-	switch n := n.Children()[0]; n.Name {
-	case "BracketedSelection":
-		segment, err := ParseBracketedSelection(n)
-		if err != nil {
-			return nil, err
-		}
-		return &DescendantSegment{
-			Segment: segment,
-		}, nil
-	case "WildcardSelector":
-		return &DescendantSegment{
-			Segment: new(WildcardSelector),
-		}, nil
-	case "MemberNameShorthand":
-		return &DescendantSegment{
-			Segment: &MemberNameShorthand{
-				Name: n.Value(),
-			},
-		}, nil
-	default:
-		return nil, NewInvalidNodeStructureError(name, n)
+	segment, err := parseSelection(n.Children()[0])
+	if err != nil {
+		return nil, err
 	}
+	return &DescendantSegment{
+		Segment: segment,
+	}, nil
 }
 
 func (s DescendantSegment) String() string {
-	switch s := s.Segment.(type) {
-	case *MemberNameShorthand:
-		return fmt.Sprintf("..%s", s.Name)
-	}
 	return fmt.Sprintf("..%s", s.Segment)
 }
 
@@ -301,6 +302,19 @@ func ParseFunctionArgument(n *parser.Node) (FunctionArgument, error) {
 	case "JsonpathQuery":
 		return ParseJSONPathQuery(n)
 	case "LogicalExpr":
+		// Logical expressions are tried first, so that comparisons and logical operators can be arguments. A query or
+		// a function expression is also a valid logical expression, in which case the logical expression consists of
+		// a single test expression. The query or the function expression is the actual argument.
+		if operand := testExprOperand(n); operand != nil {
+			switch operand.Name {
+			case "RelQuery":
+				return ParseRelQuery(operand)
+			case "JsonpathQuery":
+				return ParseJSONPathQuery(operand)
+			case "FunctionExpr":
+				return ParseFunctionExpr(operand)
+			}
+		}
 		return ParseLogicalExpr(n)
 	case "FunctionExpr":
 		return ParseFunctionExpr(n)
@@ -309,6 +323,22 @@ func ParseFunctionArgument(n *parser.Node) (FunctionArgument, error) {
 	}
 }
 
+// testExprOperand returns the operand of a logical expression that consists of a single, non-negated test expression.
+// Otherwise, it returns nil.
+func testExprOperand(n *parser.Node) *parser.Node {
+	for _, name := range []string{"LogicalAndExpr", "BasicExpr", "TestExpr"} {
+		cs := n.Children()
+		if len(cs) != 1 || cs[0].Name != name {
+			return nil
+		}
+		n = cs[0]
+	}
+	if cs := n.Children(); len(cs) == 1 {
+		return cs[0]
+	}
+	return nil
+}
+
 type FunctionExpr struct {
 	Name      string
 	Arguments []FunctionArgument
@@ -332,40 +362,6 @@ func ParseFunctionExpr(n *parser.Node) (*FunctionExpr, error) {
 		}
 		args = append(args, arg)
 	}
-	switch functionName {
-	case "length":
-		if l := len(args); l != 1 {
-			return nil, fmt.Errorf("invalid number of arguments (%d) for %s", l, functionName)
-		}
-		if arg := args[0]; typeOfArgument(arg) != valueType {
-			return nil, fmt.Errorf("invalid arg type %s for %s", arg, functionName)
-		}
-	case "count":
-		if l := len(args); l != 1 {
-			return nil, fmt.Errorf("invalid number of arguments (%d) for %s", l, functionName)
-		}
-		if arg := args[0]; typeOfArgument(arg) != nodesType {
-			return nil, fmt.Errorf("invalid arg type %s for %s", arg, functionName)
-		}
-	case "value":
-		if l := len(args); l != 1 {
-			return nil, fmt.Errorf("invalid number of arguments (%d) for %s", l, functionName)
-		}
-		if typ := typeOfArgument(args[0]); typ != nodesType && typ != valueType {
-			return nil, fmt.Errorf("invalid arg type %s for %s", args[0], functionName)
-		}
-	case "match", "search":
-		if l := len(args); l != 2 {
-			return nil, fmt.Errorf("invalid number of arguments (%d) for %s", l, functionName)
-		}
-		if arg := args[0]; typeOfArgument(arg) != valueType {
-			return nil, fmt.Errorf("invalid arg type %s for %s", arg, functionName)
-		}
-		if arg := args[1]; typeOfArgument(arg) != valueType {
-			return nil, fmt.Errorf("invalid arg type %s for %s", arg, functionName)
-		}
-	}
-
 	return &FunctionExpr{
 		Name:      functionName,
 		Arguments: args,
@@ -412,22 +408,31 @@ func (s IndexSegment) String() string {
 	return fmt.Sprintf("[%s]", s.Selector.String())
 }
 
+// Value returns the element at the index, or cmp.Nothing if the reference is not an array or the index is out of
+// range.
 func (s IndexSegment) Value(ref any) (any, error) {
-	switch ref := ref.(type) {
-	case []any:
-		if len(ref) <= s.Selector.Index {
-			return nil, nil
-		}
-		return ref[s.Selector.Index], nil
-	default:
-		return nil, fmt.Errorf("unsupported ref type: %T", ref)
+	n, ok := node.Of(ref)
+	if !ok || n.Kind() != node.Array {
+		return cmp.Nothing{}, nil
+	}
+	idx := s.Selector.Index
+	if idx < 0 {
+		idx += int64(n.Len())
+	}
+	if idx < 0 || int64(n.Len()) <= idx {
+		return cmp.Nothing{}, nil
+	}
+	v, ok := n.Index(int(idx))
+	if !ok {
+		return cmp.Nothing{}, nil
 	}
+	return v, nil
 }
 
 func (s IndexSegment) singularQuerySegment() {}
 
 type IndexSelector struct {
-	Index int
+	Index int64
 }
 
 func ParseIndexSelector(n *parser.Node) (*IndexSelector, error) {
@@ -435,7 +440,7 @@ func ParseIndexSelector(n *parser.Node) (*IndexSelector, error) {
 	if n.Name != name {
 		return nil, NewInvalidNodeStructureError(name, n)
 	}
-	idx, err := parseInt(n.Children()[0])
+	idx, err := parseInt(n.Children()[0], rawSelector(n.Children()[0].Value()))
 	if err != nil {
 		return nil, err
 	}
@@ -512,7 +517,7 @@ func (s LogicalAndExpr) String() string {
 	for _, e := range s.Expressions {
 		str = append(str, e.String())
 	}
-	return strings.Join(str, "&&")
+	return strings.Join(str, " && ")
 }
 
 type LogicalExpr struct {
@@ -547,18 +552,6 @@ func (s LogicalExpr) String() string {
 
 func (s LogicalExpr) argument() {}
 
-type MemberNameShorthand struct {
-	Name string
-}
-
-func (s MemberNameShorthand) String() string {
-	return fmt.Sprintf("['%s']", s.Name)
-}
-
-func (s MemberNameShorthand) childSegment() {}
-
-func (s MemberNameShorthand) segment() {}
-
 type NameSegment struct {
 	Name string
 }
@@ -587,16 +580,21 @@ func ParseNameSegment(n *parser.Node) (*NameSegment, error) {
 }
 
 func (s NameSegment) String() string {
-	return fmt.Sprintf("[%s]", s.Name)
+	return fmt.Sprintf("[%s]", Quote(s.Name))
 }
 
+// Value returns the member value with the name, or cmp.Nothing if the reference is not an object or has no such
+// member.
 func (s NameSegment) Value(ref any) (any, error) {
-	switch ref := ref.(type) {
-	case map[string]any:
-		return ref[s.Name], nil
-	default:
-		return nil, nil
+	n, ok := node.Of(ref)
+	if !ok {
+		return cmp.Nothing{}, nil
 	}
+	v, ok := n.Member(s.Name)
+	if !ok {
+		return cmp.Nothing{}, nil
+	}
+	return v, nil
 }
 
 func (s NameSegment) singularQuerySegment() {}
@@ -620,8 +618,7 @@ func ParseNameSelector(n *parser.Node) (*NameSelector, error) {
 }
 
 func (s NameSelector) String() string {
-	name := strings.Replace(s.Name, "'", "\\'", -1)
-	return fmt.Sprintf("'%s'", name)
+	return Quote(s.Name)
 }
 
 func (s NameSelector) selector() {}
@@ -848,8 +845,10 @@ func ParseSingularQuerySegments(n *parser.Node) ([]SingularQuerySegment, error)
 	return segments, nil
 }
 
+// SliceSelector selects a slice of an array. Start, End and Step are nil if they are absent in the query, in which case
+// defaults are used that depend on the length of the array and the step.
 type SliceSelector struct {
-	Start, End, Step int
+	Start, End, Step *int64
 }
 
 func ParseSliceSelector(n *parser.Node) (*SliceSelector, error) {
@@ -857,49 +856,79 @@ func ParseSliceSelector(n *parser.Node) (*SliceSelector, error) {
 	if n.Name != name {
 		return nil, NewInvalidNodeStructureError(name, n)
 	}
-	start, end, step := 0, -1, 1
-	for _, n := range n.Children() {
-		switch n.Name {
+	raw := map[string]string{}
+	for _, c := range n.Children() {
+		raw[c.Name] = c.Children()[0].Value()
+	}
+	text := rawSelector(raw["Start"] + ":" + raw["End"])
+	if step, ok := raw["Step"]; ok {
+		text += rawSelector(":" + step)
+	}
+	var selector SliceSelector
+	for _, c := range n.Children() {
+		idx, err := parseInt(c.Children()[0], text)
+		if err != nil {
+			return nil, err
+		}
+		switch c.Name {
 		case "Start":
-			idx, err := parseInt(n.Children()[0])
-			if err != nil {
-				return nil, err
-			}
-			start = idx
+			selector.Start = &idx
 		case "End":
-			idx, err := parseInt(n.Children()[0])
-			if err != nil {
-				return nil, err
-			}
-			end = idx
+			selector.End = &idx
 		case "Step":
-			idx, err := parseInt(n.Children()[0])
-			if err != nil {
-				return nil, err
-			}
-			step = idx
+			selector.Step = &idx
 		default:
-			return nil, NewInvalidNodeStructureError(name, n)
+			return nil, NewInvalidNodeStructureError(name, c)
 		}
 	}
-	return &SliceSelector{
-		Start: start,
-		End:   end,
-		Step:  step,
-	}, nil
+	return &selector, nil
+}
+
+// Bounds returns the lower and upper bounds, and the step of the slice for an array of the given length, as defined
+// in RFC 9535 §2.3.4.2.2. If the step is positive, the selected indices are lower <= i < upper, in ascending order.
+// If the step is negative, the selected indices are lower < i <= upper, in descending order. If the step is zero,
+// nothing is selected. The bounds are within [-1, length], but the step is not, so they are returned as int64.
+func (s SliceSelector) Bounds(length int) (lower, upper, step int64) {
+	n := int64(length)
+	step = 1
+	if s.Step != nil {
+		step = *s.Step
+	}
+	start, end := int64(0), n
+	if step < 0 {
+		start, end = n-1, -n-1
+	}
+	if s.Start != nil {
+		start = *s.Start
+	}
+	if s.End != nil {
+		end = *s.End
+	}
+
+	normalize := func(i int64) int64 {
+		if 0 <= i {
+			return i
+		}
+		return n + i
+	}
+	start, end = normalize(start), normalize(end)
+	if 0 <= step {
+		return min(max(start, 0), n), min(max(end, 0), n), step
+	}
+	return min(max(end, -1), n-1), min(max(start, -1), n-1), step
 }
 
 func (s SliceSelector) String() string {
 	var str string
-	if 0 < s.Start {
-		str += fmt.Sprintf("%d", s.Start)
+	if s.Start != nil {
+		str += fmt.Sprintf("%d", *s.Start)
 	}
 	str += ":"
-	if 0 < s.End {
-		str += fmt.Sprintf("%d", s.End)
+	if s.End != nil {
+		str += fmt.Sprintf("%d", *s.End)
 	}
-	if 0 != s.Step && 1 != s.Step {
-		str += fmt.Sprintf(":%d", s.Step)
+	if s.Step != nil {
+		str += fmt.Sprintf(":%d", *s.Step)
 	}
 	return str
 }
@@ -926,9 +955,6 @@ func ParseTestExpr(n *parser.Node) (*TestExpr, error) {
 			if err != nil {
 				return nil, err
 			}
-			if typ := typeOfArgument(q); typ != logicalType {
-				return nil, fmt.Errorf("invalid arg type %s for %s", q, name)
-			}
 			return &TestExpr{
 				Negation: negation,
 				TestExpr: q,
@@ -980,55 +1006,4 @@ func (s WildcardSelector) String() string {
 	return "*"
 }
 
-func (s WildcardSelector) childSegment() {}
-
-func (s WildcardSelector) segment() {}
-
 func (s WildcardSelector) selector() {}
-
-type argumentType int
-
-const (
-	unknownType argumentType = iota
-	valueType
-	logicalType
-	nodesType
-)
-
-func typeOfArgument(arg any) argumentType {
-	switch arg := arg.(type) {
-	case *JSONPathQuery:
-		for _, segment := range arg.Segments {
-			if typ := typeOfArgument(segment); typ != valueType {
-				return typ
-			}
-		}
-		return valueType
-	case *FunctionExpr:
-		switch arg.Name {
-		case "length", "count", "value":
-			return valueType
-		case "match", "search":
-			return logicalType
-		default:
-			return unknownType
-		}
-	case *WildcardSelector:
-		return nodesType
-	case *DescendantSegment:
-		return typeOfArgument(arg.Segment)
-	case *RelSingularQuery, *AbsSingularQuery, *MemberNameShorthand:
-		return valueType
-	case *RelQuery:
-		for _, segment := range arg.Segments {
-			if typ := typeOfArgument(segment); typ != valueType {
-				return typ
-			}
-		}
-		return valueType
-	case *Boolean, *Null, *Number, *String:
-		return valueType
-	default:
-		panic(fmt.Sprintf("unsupported arg type %T", arg))
-	}
-}
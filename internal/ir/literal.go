@@ -1,11 +1,13 @@
 package ir
 
 import (
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"github.com/0x51-dev/upeg/parser"
+	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf16"
 )
 
 // Boolean = true / false
@@ -21,7 +23,7 @@ func (s Boolean) String() string {
 }
 
 func (s Boolean) Value(_ any) (any, error) {
-	return s, nil
+	return bool(s), nil
 }
 
 func (s Boolean) argument() {}
@@ -57,6 +59,9 @@ func ParseLiteral(n *parser.Node) (Literal, error) {
 		for _, n := range n.Children() {
 			number += n.Value()
 		}
+		// The exponent is normalized, so that 1E2 and 1e+2 are written as 1e2 in the canonical form. Otherwise, the
+		// number is kept as written.
+		number = strings.Replace(strings.Replace(number, "E", "e", 1), "e+", "e", 1)
 		lit := Number(number)
 		return &lit, nil
 	case "StringLiteral":
@@ -96,7 +101,7 @@ func (s Null) literal() {}
 // DIGIT  = %x30-39              ; 0-9
 // DIGIT1 = %x31-39                    ; 1-9 non-zero digit
 // frac   = "." 1*DIGIT                  ; decimal fraction
-// exp    = "e" [ "-" / "+" ] 1*DIGIT    ; decimal exponent
+// exp    = ("e" / "E") [ "-" / "+" ] 1*DIGIT ; decimal exponent
 type Number json.Number
 
 func (s Number) String() string {
@@ -105,7 +110,7 @@ func (s Number) String() string {
 
 func (s Number) Value(_ any) (any, error) {
 	jn := json.Number(s)
-	if strings.Contains(string(jn), ".") || strings.Contains(string(jn), "e") {
+	if strings.Contains(string(jn), ".") || strings.ContainsAny(string(jn), "eE") {
 		return jn.Float64()
 	}
 	return jn.Int64()
@@ -127,7 +132,7 @@ func (s Number) literal() {}
 // non-surrogate  = ((DIGIT / "A"/"B"/"C" / "E"/"F") 3HEXDIG) / ("D" %x30-37 2HEXDIG )
 // high-surrogate = "D" ("8"/"9"/"A"/"B") 2HEXDIG
 // low-surrogate  = "D" ("C"/"D"/"E"/"F") 2HEXDIG
-// HEXDIG         = DIGIT / "A" / "B" / "C" / "D" / "E" / "F"
+// HEXDIG         = DIGIT / "A" / "B" / "C" / "D" / "E" / "F" (case-insensitive)
 type String string
 
 func ParseStringLiteral(n *parser.Node) (*String, error) {
@@ -167,11 +172,11 @@ func ParseStringLiteral(n *parser.Node) (*String, error) {
 				if n.Name != "Hexchar" {
 					return nil, NewInvalidNodeStructureError(name, n)
 				}
-				raw, err := hex.DecodeString(n.Value())
+				r, err := decodeHexchar(n.Value())
 				if err != nil {
 					return nil, NewInvalidNodeStructureError(name, n)
 				}
-				str += string(raw)
+				str += string(r)
 			}
 		default:
 			return nil, NewInvalidNodeStructureError(name, n)
@@ -182,7 +187,7 @@ func ParseStringLiteral(n *parser.Node) (*String, error) {
 }
 
 func (s String) String() string {
-	return fmt.Sprintf("'%s'", string(s))
+	return Quote(string(s))
 }
 
 func (s String) Value(_ any) (any, error) {
@@ -194,3 +199,59 @@ func (s String) argument() {}
 func (s String) comparable() {}
 
 func (s String) literal() {}
+
+// Quote returns the string as a single-quoted string literal. Quotes, backslashes and control characters are escaped
+// as described by the normal-single-quoted rule of RFC 9535 §2.7, so the result is also valid in a normalized path.
+func Quote(s string) string {
+	var str strings.Builder
+	str.WriteByte('\'')
+	for _, r := range s {
+		switch r {
+		case '\b':
+			str.WriteString(`\b`)
+		case '\f':
+			str.WriteString(`\f`)
+		case '\n':
+			str.WriteString(`\n`)
+		case '\r':
+			str.WriteString(`\r`)
+		case '\t':
+			str.WriteString(`\t`)
+		case '\'':
+			str.WriteString(`\'`)
+		case '\\':
+			str.WriteString(`\\`)
+		default:
+			if r < 0x20 {
+				str.WriteString(fmt.Sprintf(`\u%04x`, r))
+				continue
+			}
+			str.WriteRune(r)
+		}
+	}
+	str.WriteByte('\'')
+	return str.String()
+}
+
+// decodeHexchar decodes the hexadecimal digits of an escaped character, either a single UTF-16 code unit (XXXX) or
+// a surrogate pair (XXXX\uXXXX).
+func decodeHexchar(v string) (rune, error) {
+	if len(v) != 4 && len(v) != 10 {
+		return 0, fmt.Errorf("invalid escaped character: %q", v)
+	}
+	r, err := strconv.ParseUint(v[:4], 16, 16)
+	if err != nil {
+		return 0, err
+	}
+	if len(v) == 4 {
+		return rune(r), nil
+	}
+	low, err := strconv.ParseUint(v[6:], 16, 16)
+	if err != nil {
+		return 0, err
+	}
+	if d := utf16.DecodeRune(rune(r), rune(low)); d != unicode.ReplacementChar {
+		return d, nil
+	}
+	return 0, fmt.Errorf("invalid surrogate pair: %q", v)
+}
@@ -30,6 +30,8 @@ func TestParseLiteral(t *testing.T) {
 			{"1e-1", "0.1"},
 			{"1e+1", "10"},
 			{"1.1e1", "11"},
+			{"1E2", "100"},
+			{"1.5E-1", "0.15"},
 		} {
 			p, err := parser.New([]rune(test.input))
 			if err != nil {
@@ -61,7 +63,9 @@ func TestParseLiteral(t *testing.T) {
 			{`'hello'`, "hello"},
 			{`'he"llo'`, `he"llo`},
 			{`"he'llo'"`, `he'llo'`},
-			{`"\/\\\b\f\n\r\t\uF09F\u8DBA"`, "/\\\b\f\n\r\t🍺"},
+			{`"\/\\\b\f\n\r\t\uD83C\uDF7A"`, "/\\\b\f\n\r\t🍺"},
+			{`"\u00e9\u00E9\u263a"`, "éé☺"},
+			{`'\ud834\udd1e'`, "𝄞"},
 			{`"he\"llo"`, `he"llo`},
 			{`'he\'llo'`, `he'llo`},
 		} {
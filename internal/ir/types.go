@@ -0,0 +1,234 @@
+package ir
+
+import (
+	"fmt"
+	"github.com/0x51-dev/jsonpath/cmp"
+	"sort"
+	"strings"
+)
+
+// Type is the declared type of a function parameter or result, as defined in RFC 9535 §2.4.1.
+type Type int
+
+const (
+	// ValueType is the type of any JSON value, and of Nothing.
+	ValueType Type = iota + 1
+	// LogicalType is the type of the logical values LogicalTrue and LogicalFalse.
+	LogicalType
+	// NodesType is the type of node lists.
+	NodesType
+)
+
+func (t Type) String() string {
+	switch t {
+	case ValueType:
+		return "ValueType"
+	case LogicalType:
+		return "LogicalType"
+	case NodesType:
+		return "NodesType"
+	default:
+		return fmt.Sprintf("Type(%d)", int(t))
+	}
+}
+
+// FunctionType is the signature of a function extension.
+type FunctionType struct {
+	Parameters []Type
+	Result     Type
+	// Validate optionally checks the literal arguments of a function expression, all other arguments are
+	// cmp.Nothing.
+	Validate func(args []any) error
+}
+
+// Check verifies that the query is well-typed (RFC 9535 §2.4.3), given the signatures of the available function
+// extensions.
+func Check(q *JSONPathQuery, functions map[string]FunctionType) error {
+	return checker{functions: functions}.checkSegments(q.Segments)
+}
+
+type checker struct {
+	functions map[string]FunctionType
+}
+
+// checkArgument verifies that the argument is well-typed for a parameter of the given type.
+func (c checker) checkArgument(arg FunctionArgument, param Type) error {
+	switch arg := arg.(type) {
+	case Literal:
+		if param != ValueType {
+			return NewTypeError(arg, "literal can not be used as %s", param)
+		}
+		return nil
+	case *RelQuery:
+		return c.checkQueryArgument(arg, arg.Segments, param)
+	case *JSONPathQuery:
+		return c.checkQueryArgument(arg, arg.Segments, param)
+	case *LogicalExpr:
+		if param != LogicalType {
+			return NewTypeError(arg, "logical expression can not be used as %s", param)
+		}
+		return c.checkLogicalExpr(arg)
+	case *FunctionExpr:
+		result, err := c.checkFunctionExpr(arg)
+		if err != nil {
+			return err
+		}
+		// A function result of NodesType can be converted to LogicalType.
+		if result != param && (param != LogicalType || result != NodesType) {
+			return NewTypeError(arg, "result of type %s can not be used as %s", result, param)
+		}
+		return nil
+	default:
+		return NewTypeError(arg, "unsupported argument type %T", arg)
+	}
+}
+
+func (c checker) checkBasicExpr(expr BasicExpr) error {
+	switch expr := expr.(type) {
+	case *ParenExpr:
+		return c.checkLogicalExpr(expr.LogicalExpr)
+	case *ComparisonExpr:
+		if err := c.checkComparable(expr.Left); err != nil {
+			return err
+		}
+		return c.checkComparable(expr.Right)
+	case *TestExpr:
+		switch test := expr.TestExpr.(type) {
+		case *RelQuery:
+			return c.checkSegments(test.Segments)
+		case *JSONPathQuery:
+			return c.checkSegments(test.Segments)
+		case *FunctionExpr:
+			result, err := c.checkFunctionExpr(test)
+			if err != nil {
+				return err
+			}
+			if result != LogicalType && result != NodesType {
+				return NewTypeError(test, "result of type %s can not be used as test expression", result)
+			}
+			return nil
+		default:
+			return NewTypeError(expr, "unsupported test expression type %T", test)
+		}
+	default:
+		return NewTypeError(expr, "unsupported basic expression type %T", expr)
+	}
+}
+
+// checkComparable verifies that the comparable is of ValueType. Literals and singular queries always are.
+func (c checker) checkComparable(comparable Comparable) error {
+	if f, ok := comparable.(*FunctionExpr); ok {
+		result, err := c.checkFunctionExpr(f)
+		if err != nil {
+			return err
+		}
+		if result != ValueType {
+			return NewTypeError(f, "result of type %s can not be compared", result)
+		}
+	}
+	return nil
+}
+
+// checkFunctionExpr verifies the arguments of the function expression, and returns its result type.
+func (c checker) checkFunctionExpr(f *FunctionExpr) (Type, error) {
+	typ, ok := c.functions[f.Name]
+	if !ok {
+		names := make([]string, 0, len(c.functions))
+		for name := range c.functions {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return 0, NewTypeError(f, "unknown function %q, available functions are: %s", f.Name, strings.Join(names, ", "))
+	}
+	if len(f.Arguments) != len(typ.Parameters) {
+		return 0, NewTypeError(f, "expected %d arguments, got %d", len(typ.Parameters), len(f.Arguments))
+	}
+	literals := make([]any, len(f.Arguments))
+	for i, arg := range f.Arguments {
+		if err := c.checkArgument(arg, typ.Parameters[i]); err != nil {
+			return 0, err
+		}
+		literals[i] = cmp.Nothing{}
+		if l, ok := arg.(Literal); ok {
+			v, err := l.Value(nil)
+			if err != nil {
+				return 0, err
+			}
+			literals[i] = v
+		}
+	}
+	if typ.Validate != nil {
+		if err := typ.Validate(literals); err != nil {
+			return 0, err
+		}
+	}
+	return typ.Result, nil
+}
+
+func (c checker) checkLogicalExpr(expr *LogicalExpr) error {
+	for _, and := range expr.Expressions {
+		for _, e := range and.Expressions {
+			if err := c.checkBasicExpr(e); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkQueryArgument verifies a filter query argument. Filter queries are of NodesType, which can be converted to
+// LogicalType. Only singular queries can be used as ValueType.
+func (c checker) checkQueryArgument(arg FunctionArgument, segments []Segment, param Type) error {
+	if err := c.checkSegments(segments); err != nil {
+		return err
+	}
+	if param == ValueType && !IsSingular(segments) {
+		return NewTypeError(arg, "non-singular query can not be used as %s", param)
+	}
+	return nil
+}
+
+// checkSegments verifies the filter selectors within the segments.
+func (c checker) checkSegments(segments []Segment) error {
+	for _, segment := range segments {
+		var s *BracketedSelection
+		switch segment := segment.(type) {
+		case *BracketedSelection:
+			s = segment
+		case *DescendantSegment:
+			s = segment.Segment
+		}
+		if s == nil {
+			continue
+		}
+		for _, selector := range s.Selectors {
+			if f, ok := selector.(*FilterSelector); ok {
+				if err := c.checkLogicalExpr(f.LogicalExpr); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// IsSingular reports whether the segments form a singular query, i.e. a query that selects at most one node. Only
+// child segments with a single name or index selector are singular.
+func IsSingular(segments []Segment) bool {
+	for _, segment := range segments {
+		switch segment := segment.(type) {
+		case *BracketedSelection:
+			if len(segment.Selectors) != 1 {
+				return false
+			}
+			switch segment.Selectors[0].(type) {
+			case *NameSelector, *IndexSelector:
+			default:
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
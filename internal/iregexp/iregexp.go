@@ -0,0 +1,428 @@
+// Package iregexp implements I-Regexp (RFC 9485), an interoperable regular expression format, by translating I-Regexp
+// patterns to equivalent patterns of the regexp package.
+package iregexp
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// CompileMatch compiles the I-Regexp pattern into a regular expression that only matches entire strings.
+func CompileMatch(pattern string) (*regexp.Regexp, error) {
+	re, err := Translate(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return regexp.Compile(`\A(?:` + re + `)\z`)
+}
+
+// CompileSearch compiles the I-Regexp pattern into a regular expression that matches any substring.
+func CompileSearch(pattern string) (*regexp.Regexp, error) {
+	re, err := Translate(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return regexp.Compile(re)
+}
+
+// Translate validates the I-Regexp pattern, and returns an equivalent pattern in the syntax of the regexp package.
+// The returned pattern is not anchored.
+func Translate(pattern string) (string, error) {
+	t := translator{pattern: pattern, input: []rune(pattern)}
+	if err := t.iRegexp(); err != nil {
+		return "", err
+	}
+	if !t.done() {
+		return "", t.newSyntaxError("unexpected %q", t.peek())
+	}
+	return t.out.String(), nil
+}
+
+// SyntaxError is returned when a pattern is not a valid I-Regexp.
+type SyntaxError struct {
+	Pattern string
+	Offset  int
+	Reason  string
+}
+
+// Error returns the error message.
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("invalid I-Regexp %q at offset %d: %s", e.Pattern, e.Offset, e.Reason)
+}
+
+var (
+	// assigned are the categories that, together with Cn (unassigned), make up all code points.
+	assigned = []string{"L", "M", "N", "P", "S", "Z", "Cc", "Cf", "Co", "Cs"}
+
+	// unassigned is the character class content of the Cn category, which is not supported by all versions of the
+	// regexp package.
+	unassigned     string
+	unassignedOnce sync.Once
+)
+
+// unassignedClass returns the content of a character class that matches all unassigned code points.
+func unassignedClass() string {
+	unassignedOnce.Do(func() {
+		var tables []*unicode.RangeTable
+		for _, category := range assigned {
+			tables = append(tables, unicode.Categories[category])
+		}
+		var str strings.Builder
+		start := rune(-1)
+		for r := rune(0); r <= unicode.MaxRune+1; r++ {
+			if r <= unicode.MaxRune && !unicode.In(r, tables...) {
+				if start < 0 {
+					start = r
+				}
+				continue
+			}
+			if 0 <= start {
+				str.WriteString(fmt.Sprintf(`\x{%x}-\x{%x}`, start, r-1))
+				start = -1
+			}
+		}
+		unassigned = str.String()
+	})
+	return unassigned
+}
+
+type translator struct {
+	pattern string
+	input   []rune
+	pos     int
+	out     strings.Builder
+}
+
+// atom = NormalChar / charClass / ( "(" i-regexp ")" )
+func (t *translator) atom() error {
+	switch r := t.peek(); {
+	case r == '(':
+		t.pos++
+		t.out.WriteString("(?:")
+		if err := t.iRegexp(); err != nil {
+			return err
+		}
+		if t.done() || t.peek() != ')' {
+			return t.newSyntaxError("missing closing )")
+		}
+		t.pos++
+		t.out.WriteString(")")
+		return nil
+	case r == '.':
+		// Any character except line terminators.
+		t.pos++
+		t.out.WriteString(`[^\n\r]`)
+		return nil
+	case r == '[':
+		return t.charClassExpr()
+	case r == '\\':
+		if class, ok, err := t.charClassEsc(); ok || err != nil {
+			if err != nil {
+				return err
+			}
+			t.out.WriteString("[" + class + "]")
+			return nil
+		}
+		c, err := t.singleCharEsc()
+		if err != nil {
+			return err
+		}
+		t.out.WriteString(regexp.QuoteMeta(string(c)))
+		return nil
+	case isNormalChar(r):
+		t.pos++
+		t.out.WriteString(regexp.QuoteMeta(string(r)))
+		return nil
+	default:
+		return t.newSyntaxError("unexpected %q", r)
+	}
+}
+
+// branch = *piece
+func (t *translator) branch() error {
+	for !t.done() && t.peek() != '|' && t.peek() != ')' {
+		if err := t.piece(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ccChar = ( %x00-2C / %x2E-5A / %x5E-D7FF / %xE000-10FFFF ) / SingleCharEsc
+func (t *translator) ccChar() (rune, error) {
+	if t.done() {
+		return 0, t.newSyntaxError("missing closing ]")
+	}
+	switch r := t.peek(); r {
+	case '\\':
+		return t.singleCharEsc()
+	case '-', '[', ']':
+		return 0, t.newSyntaxError("unexpected %q in character class", r)
+	default:
+		t.pos++
+		return r, nil
+	}
+}
+
+// CCE1 = ( CCchar [ "-" CCchar ] ) / charClassEsc
+func (t *translator) cce1() error {
+	if class, ok, err := t.charClassEsc(); ok || err != nil {
+		if err != nil {
+			return err
+		}
+		t.out.WriteString(class)
+		return nil
+	}
+	low, err := t.ccChar()
+	if err != nil {
+		return err
+	}
+	// A hyphen followed by the closing bracket is not a range.
+	if t.pos+1 < len(t.input) && t.input[t.pos] == '-' && t.input[t.pos+1] != ']' {
+		t.pos++
+		high, err := t.ccChar()
+		if err != nil {
+			return err
+		}
+		if high < low {
+			return t.newSyntaxError("invalid character class range %q-%q", low, high)
+		}
+		t.out.WriteString(fmt.Sprintf(`\x{%x}-\x{%x}`, low, high))
+		return nil
+	}
+	t.out.WriteString(fmt.Sprintf(`\x{%x}`, low))
+	return nil
+}
+
+// charClassEsc = catEsc / complEsc
+// catEsc       = %s"\p{" charProp "}"
+// complEsc     = %s"\P{" charProp "}"
+//
+// Returns the content of a character class that is equivalent to the escape, or false if the input does not start
+// with a character class escape.
+func (t *translator) charClassEsc() (string, bool, error) {
+	if len(t.input) < t.pos+2 || t.input[t.pos] != '\\' || (t.input[t.pos+1] != 'p' && t.input[t.pos+1] != 'P') {
+		return "", false, nil
+	}
+	complement := t.input[t.pos+1] == 'P'
+	t.pos += 2
+	if t.done() || t.peek() != '{' {
+		return "", true, t.newSyntaxError("missing { after category escape")
+	}
+	t.pos++
+	start := t.pos
+	for !t.done() && t.peek() != '}' {
+		t.pos++
+	}
+	if t.done() {
+		return "", true, t.newSyntaxError("missing } after category escape")
+	}
+	category := string(t.input[start:t.pos])
+	t.pos++
+	if !isCategory(category) {
+		return "", true, t.newSyntaxError("unknown category %q", category)
+	}
+
+	// Go does not know the Cn category, and its C category does not include Cn.
+	switch category {
+	case "Cn":
+		if complement {
+			return `\p{` + strings.Join(assigned, `}\p{`) + `}`, true, nil
+		}
+		return unassignedClass(), true, nil
+	case "C":
+		if complement {
+			return `\p{L}\p{M}\p{N}\p{P}\p{S}\p{Z}`, true, nil
+		}
+		return `\p{Cc}\p{Cf}\p{Co}\p{Cs}` + unassignedClass(), true, nil
+	}
+	if complement {
+		return `\P{` + category + `}`, true, nil
+	}
+	return `\p{` + category + `}`, true, nil
+}
+
+// charClassExpr = "[" [ "^" ] ( "-" / CCE1 ) *CCE1 [ "-" ] "]"
+// CCE1          = ( CCchar [ "-" CCchar ] ) / charClassEsc
+func (t *translator) charClassExpr() error {
+	t.pos++ // [
+	t.out.WriteString("[")
+	if !t.done() && t.peek() == '^' {
+		t.pos++
+		t.out.WriteString("^")
+	}
+	if !t.done() && t.peek() == '-' {
+		t.pos++
+		t.out.WriteString(`\-`)
+	} else if err := t.cce1(); err != nil {
+		return err
+	}
+	for !t.done() && t.peek() != ']' {
+		if t.peek() == '-' {
+			// A trailing hyphen is only allowed right before the closing bracket.
+			t.pos++
+			if t.done() || t.peek() != ']' {
+				return t.newSyntaxError("unexpected - in character class")
+			}
+			t.out.WriteString(`\-`)
+			break
+		}
+		if err := t.cce1(); err != nil {
+			return err
+		}
+	}
+	if t.done() {
+		return t.newSyntaxError("missing closing ]")
+	}
+	t.pos++ // ]
+	t.out.WriteString("]")
+	return nil
+}
+
+func (t *translator) done() bool {
+	return len(t.input) <= t.pos
+}
+
+// i-regexp = branch *( "|" branch )
+func (t *translator) iRegexp() error {
+	for {
+		if err := t.branch(); err != nil {
+			return err
+		}
+		if t.done() || t.peek() != '|' {
+			return nil
+		}
+		t.pos++
+		t.out.WriteString("|")
+	}
+}
+
+func (t *translator) newSyntaxError(format string, args ...any) *SyntaxError {
+	return &SyntaxError{
+		Pattern: t.pattern,
+		Offset:  t.pos,
+		Reason:  fmt.Sprintf(format, args...),
+	}
+}
+
+func (t *translator) peek() rune {
+	return t.input[t.pos]
+}
+
+// piece = atom [ quantifier ]
+func (t *translator) piece() error {
+	if err := t.atom(); err != nil {
+		return err
+	}
+	if t.done() {
+		return nil
+	}
+	return t.quantifier()
+}
+
+// quantifier       = ( "*" / "+" / "?" ) / range-quantifier
+// range-quantifier = "{" QuantExact [ "," [ QuantExact ] ] "}"
+func (t *translator) quantifier() error {
+	switch t.peek() {
+	case '*', '+', '?':
+		t.out.WriteRune(t.peek())
+		t.pos++
+		return nil
+	case '{':
+		t.pos++
+		low, ok := t.quantExact()
+		if !ok {
+			return t.newSyntaxError("missing lower bound in quantifier")
+		}
+		q := "{" + low
+		if !t.done() && t.peek() == ',' {
+			t.pos++
+			q += ","
+			if high, ok := t.quantExact(); ok {
+				if len(high) < len(low) || (len(high) == len(low) && high < low) {
+					return t.newSyntaxError("invalid quantifier range {%s,%s}", low, high)
+				}
+				q += high
+			}
+		}
+		if t.done() || t.peek() != '}' {
+			return t.newSyntaxError("missing closing } in quantifier")
+		}
+		t.pos++
+		t.out.WriteString(q + "}")
+		return nil
+	default:
+		return nil
+	}
+}
+
+// QuantExact = 1*%x30-39
+func (t *translator) quantExact() (string, bool) {
+	start := t.pos
+	for !t.done() && '0' <= t.peek() && t.peek() <= '9' {
+		t.pos++
+	}
+	if start == t.pos {
+		return "", false
+	}
+	// Leading zeros do not change the value, but would make the comparison of the bounds harder.
+	digits := strings.TrimLeft(string(t.input[start:t.pos]), "0")
+	if digits == "" {
+		digits = "0"
+	}
+	return digits, true
+}
+
+// SingleCharEsc = "\" ( %x28-2B / "-" / "." / "?" / %x5B-5E / %s"n" / %s"r" / %s"t" / %x7B-7D )
+func (t *translator) singleCharEsc() (rune, error) {
+	t.pos++ // \
+	if t.done() {
+		return 0, t.newSyntaxError("trailing backslash")
+	}
+	r := t.peek()
+	switch r {
+	case '(', ')', '*', '+', '-', '.', '?', '[', '\\', ']', '^', '{', '|', '}':
+		t.pos++
+		return r, nil
+	case 'n':
+		t.pos++
+		return '\n', nil
+	case 'r':
+		t.pos++
+		return '\r', nil
+	case 't':
+		t.pos++
+		return '\t', nil
+	default:
+		return 0, t.newSyntaxError("invalid escape \\%c", r)
+	}
+}
+
+// isCategory reports whether the given name is a valid IsCategory production.
+func isCategory(name string) bool {
+	switch name {
+	case "L", "Ll", "Lm", "Lo", "Lt", "Lu",
+		"M", "Mc", "Me", "Mn",
+		"N", "Nd", "Nl", "No",
+		"P", "Pc", "Pd", "Pe", "Pf", "Pi", "Po", "Ps",
+		"Z", "Zl", "Zp", "Zs",
+		"S", "Sc", "Sk", "Sm", "So",
+		"C", "Cc", "Cf", "Cn", "Co":
+		return true
+	default:
+		return false
+	}
+}
+
+// isNormalChar reports whether the rune matches the NormalChar production, i.e. whether it represents itself.
+func isNormalChar(r rune) bool {
+	switch r {
+	case '(', ')', '*', '+', '.', '?', '[', '\\', ']', '{', '|', '}':
+		return false
+	default:
+		return r < 0xD800 || 0xE000 <= r
+	}
+}
@@ -0,0 +1,119 @@
+package iregexp
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCompileMatch(t *testing.T) {
+	for _, test := range []struct {
+		pattern string
+		input   string
+		match   bool
+	}{
+		{"a.c", "abc", true},
+		{"a.c", "a\nc", false},
+		{"a.c", "a\rc", false},
+		{"a.c", "a c", true},
+		{"ab", "xabx", false},
+		{"a|b", "b", true},
+		{"a|", "", true},
+		{"(ab)+", "abab", true},
+		{"a{2,3}", "aaa", true},
+		{"a{2,3}", "aaaa", false},
+		{"a{2,}", "aaaa", true},
+		{"a{02}", "aa", true},
+		{"^a$", "^a$", true},
+		{"^a$", "a", false},
+		{`\^$\.\\`, `^$.\`, true},
+		{`\n\r\t`, "\n\r\t", true},
+		{"[a-c]+", "abc", true},
+		{"[^a-c]", "d", true},
+		{"[^a-c]", "a", false},
+		{"[-a]+", "-a", true},
+		{"[a-]+", "-a", true},
+		{`[\]\-\\]+`, `]-\`, true},
+		{"[^]a]", "b", false},
+		{`\p{Lu}+`, "ABC", true},
+		{`\p{Lu}`, "a", false},
+		{`\P{Lu}`, "a", true},
+		{`[\p{Ll}\d]`, "a", false},
+		{`[\p{Nd}x]+`, "1x2", true},
+		{`\p{Cn}`, "\U000E0080", true},
+		{`\p{Cn}`, "a", false},
+		{`\P{Cn}`, "a", true},
+		{`\p{C}`, "\U000E0080", true},
+		{`\p{C}`, "\u0000", true},
+		{`[^\p{C}]`, "\u0000", false},
+		{"☕+", "☕☕", true},
+	} {
+		t.Run(test.pattern, func(t *testing.T) {
+			r, err := CompileMatch(test.pattern)
+			if err != nil {
+				if test.match {
+					t.Fatal(err)
+				}
+				return
+			}
+			if r.MatchString(test.input) != test.match {
+				t.Errorf("match(%q, %q) = %t, want %t", test.input, test.pattern, !test.match, test.match)
+			}
+		})
+	}
+}
+
+func TestCompileSearch(t *testing.T) {
+	r, err := CompileSearch("b.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.MatchString("abc") {
+		t.Error("expected a match")
+	}
+	if r.MatchString("ab\n") {
+		t.Error("expected no match")
+	}
+}
+
+func TestTranslate_invalid(t *testing.T) {
+	for _, pattern := range []string{
+		`\d`,
+		`\w`,
+		`\s`,
+		`\b`,
+		`a*?`,
+		`a+?`,
+		`a{2,1}`,
+		`a{,2}`,
+		`a{2`,
+		`*a`,
+		`a**`,
+		`(?:a)`,
+		`(?i)a`,
+		`(a`,
+		`a)`,
+		`[a`,
+		`[]`,
+		`[^]`,
+		`[b-a]`,
+		`[a-b-c]`,
+		`[[]`,
+		`a]`,
+		`a}`,
+		`\p{IsBasicLatin}`,
+		`\p{Xx}`,
+		`\p{L`,
+		`\pL`,
+		`\`,
+		`\x41`,
+		`\1`,
+	} {
+		t.Run(pattern, func(t *testing.T) {
+			_, err := Translate(pattern)
+			var syntaxError *SyntaxError
+			if !errors.As(err, &syntaxError) {
+				t.Errorf("expected a syntax error, got %v", err)
+			}
+		})
+	}
+}
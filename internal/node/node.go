@@ -0,0 +1,241 @@
+// Package node provides the document model of the evaluator: every value that a query is applied to is accessed
+// through the Node interface.
+package node
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/0x51-dev/jsonpath/cmp"
+	"sort"
+)
+
+// Kind is the JSON type of a node.
+type Kind int
+
+const (
+	// Null is the kind of the JSON value null.
+	Null Kind = iota + 1
+	// Boolean is the kind of the JSON values true and false.
+	Boolean
+	// Number is the kind of JSON numbers.
+	Number
+	// String is the kind of JSON strings.
+	String
+	// Array is the kind of JSON arrays.
+	Array
+	// Object is the kind of JSON objects.
+	Object
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Null:
+		return "null"
+	case Boolean:
+		return "boolean"
+	case Number:
+		return "number"
+	case String:
+		return "string"
+	case Array:
+		return "array"
+	case Object:
+		return "object"
+	default:
+		return fmt.Sprintf("Kind(%d)", int(k))
+	}
+}
+
+// Node is a JSON value of a document. The children of a node, returned by Member and Index, can be Nodes themselves
+// or any other value that Of supports.
+type Node interface {
+	// Kind returns the JSON type of the node.
+	Kind() Kind
+	// Member returns the value of the member with the given name, if the node is an object that has such a member.
+	Member(name string) (any, bool)
+	// Index returns the element at the given index, if the node is an array and 0 <= i < Len().
+	Index(i int) (any, bool)
+	// Len returns the number of elements of an array or the number of members of an object, and 0 otherwise.
+	Len() int
+	// Keys returns the member names of an object in document order, and nil otherwise. This is the order in which
+	// queries visit the members, e.g. for wildcards and descendant segments. Callers must not modify the result, so
+	// implementations can return a slice that they keep internally.
+	Keys() []string
+	// Value returns the value of a null, boolean, number or string node: nil, a bool, a number of any type supported
+	// by cmp.Numeric, or a string. The result is unspecified for arrays and objects.
+	Value() any
+}
+
+// Of returns the node of the given value. Nodes are returned as they are, decoded JSON (map[string]any, []any and
+// scalars) and any other Go value are represented like their encoding/json representation. The second return value
+// is false if the value has no JSON representation, e.g. cmp.Nothing or a function.
+func Of(v any) (Node, bool) {
+	switch v := v.(type) {
+	case Node:
+		return v, true
+	case map[string]any:
+		return object(v), true
+	case []any:
+		return array(v), true
+	case nil:
+		return scalar{kind: Null}, true
+	case bool:
+		return scalar{kind: Boolean, value: v}, true
+	case string:
+		return scalar{kind: String, value: v}, true
+	case cmp.Nothing:
+		return nil, false
+	}
+	if _, ok := cmp.Numeric(v); ok {
+		return scalar{kind: Number, value: v}, true
+	}
+	return reflectNode(v)
+}
+
+// JSON returns the value in its JSON representation: nil, a bool, a number, a string, a []any or a map[string]any.
+// Decoded JSON is returned as is, other arrays and objects are converted recursively, including a map[string]any or
+// []any that contains other values. Values without a JSON representation, e.g. cmp.Nothing, are returned as they are.
+func JSON(v any) any {
+	if isJSON(v) {
+		return v
+	}
+	n, ok := Of(v)
+	if !ok {
+		return v
+	}
+	switch n.Kind() {
+	case Array:
+		a := make([]any, n.Len())
+		for i := range a {
+			e, _ := n.Index(i)
+			a[i] = JSON(e)
+		}
+		return a
+	case Object:
+		o := make(map[string]any, n.Len())
+		for _, name := range n.Keys() {
+			m, _ := n.Member(name)
+			o[name] = JSON(m)
+		}
+		return o
+	default:
+		return n.Value()
+	}
+}
+
+// isJSON reports whether the value is already in its JSON representation, i.e. decoded JSON that does not contain any
+// other values.
+func isJSON(v any) bool {
+	switch v := v.(type) {
+	case nil, bool, string, float64, int, int64, json.Number, cmp.Nothing:
+		return true
+	case map[string]any:
+		for _, e := range v {
+			if !isJSON(e) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		for _, e := range v {
+			if !isJSON(e) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+type array []any
+
+func (a array) Kind() Kind {
+	return Array
+}
+
+func (a array) Member(string) (any, bool) {
+	return nil, false
+}
+
+func (a array) Index(i int) (any, bool) {
+	if i < 0 || len(a) <= i {
+		return nil, false
+	}
+	return a[i], true
+}
+
+func (a array) Len() int {
+	return len(a)
+}
+
+func (a array) Keys() []string {
+	return nil
+}
+
+func (a array) Value() any {
+	return []any(a)
+}
+
+type object map[string]any
+
+func (o object) Kind() Kind {
+	return Object
+}
+
+func (o object) Member(name string) (any, bool) {
+	v, ok := o[name]
+	return v, ok
+}
+
+func (o object) Index(int) (any, bool) {
+	return nil, false
+}
+
+func (o object) Len() int {
+	return len(o)
+}
+
+// Keys returns the member names sorted in ascending order, since a map has no document order.
+func (o object) Keys() []string {
+	keys := make([]string, 0, len(o))
+	for key := range o {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (o object) Value() any {
+	return map[string]any(o)
+}
+
+// scalar is a null, boolean, number or string node.
+type scalar struct {
+	kind  Kind
+	value any
+}
+
+func (s scalar) Kind() Kind {
+	return s.kind
+}
+
+func (s scalar) Member(string) (any, bool) {
+	return nil, false
+}
+
+func (s scalar) Index(int) (any, bool) {
+	return nil, false
+}
+
+func (s scalar) Len() int {
+	return 0
+}
+
+func (s scalar) Keys() []string {
+	return nil
+}
+
+func (s scalar) Value() any {
+	return s.value
+}
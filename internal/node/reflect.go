@@ -0,0 +1,406 @@
+package node
+
+import (
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// reflectNode returns the node of a Go value, based on the representation of encoding/json: structs and maps with
+// string or integer keys are objects, slices and arrays are arrays, and pointers are dereferenced. Values that
+// implement json.Marshaler or encoding.TextMarshaler are represented by their marshaled JSON.
+func reflectNode(v any) (Node, bool) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return scalar{kind: Null}, true
+		}
+		if rv.Type().Implements(jsonMarshalerType) || rv.Type().Implements(textMarshalerType) {
+			break
+		}
+		rv = rv.Elem()
+	}
+	if rv.Type().Implements(jsonMarshalerType) {
+		v, ok := marshalNode(rv.Interface().(json.Marshaler))
+		if !ok {
+			return nil, false
+		}
+		return Of(v)
+	}
+	if rv.Type().Implements(textMarshalerType) {
+		text, err := rv.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return nil, false
+		}
+		return scalar{kind: String, value: string(text)}, true
+	}
+	switch rv.Kind() {
+	case reflect.String:
+		return scalar{kind: String, value: rv.String()}, true
+	case reflect.Bool:
+		return scalar{kind: Boolean, value: rv.Bool()}, true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return scalar{kind: Number, value: rv.Interface()}, true
+	case reflect.Struct:
+		return structNode{value: rv, fields: structFields(rv.Type())}, true
+	case reflect.Map:
+		if !isMapKey(rv.Type().Key()) {
+			return nil, false
+		}
+		if rv.IsNil() {
+			return scalar{kind: Null}, true
+		}
+		return mapNode{value: rv}, true
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return scalar{kind: Null}, true
+		}
+		if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8 {
+			// Byte slices are marshaled as base64 encoded strings.
+			return scalar{kind: String, value: base64.StdEncoding.EncodeToString(rv.Bytes())}, true
+		}
+		return sliceNode{value: rv}, true
+	default:
+		return nil, false
+	}
+}
+
+// mapNode is a map with string or integer keys.
+type mapNode struct {
+	value reflect.Value
+}
+
+func (m mapNode) Kind() Kind {
+	return Object
+}
+
+func (m mapNode) Member(name string) (any, bool) {
+	if m.value.Type().Key().Kind() == reflect.String {
+		v := m.value.MapIndex(reflect.ValueOf(name).Convert(m.value.Type().Key()))
+		if !v.IsValid() {
+			return nil, false
+		}
+		return v.Interface(), true
+	}
+	for it := m.value.MapRange(); it.Next(); {
+		if key, ok := mapKey(it.Key()); ok && key == name {
+			return it.Value().Interface(), true
+		}
+	}
+	return nil, false
+}
+
+func (m mapNode) Index(int) (any, bool) {
+	return nil, false
+}
+
+func (m mapNode) Len() int {
+	return len(m.Keys())
+}
+
+// Keys returns the member names sorted in ascending order, since a map has no document order.
+func (m mapNode) Keys() []string {
+	keys := make([]string, 0, m.value.Len())
+	for it := m.value.MapRange(); it.Next(); {
+		if key, ok := mapKey(it.Key()); ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (m mapNode) Value() any {
+	return m.value.Interface()
+}
+
+// sliceNode is a slice or an array.
+type sliceNode struct {
+	value reflect.Value
+}
+
+func (s sliceNode) Kind() Kind {
+	return Array
+}
+
+func (s sliceNode) Member(string) (any, bool) {
+	return nil, false
+}
+
+func (s sliceNode) Index(i int) (any, bool) {
+	if i < 0 || s.value.Len() <= i {
+		return nil, false
+	}
+	return s.value.Index(i).Interface(), true
+}
+
+func (s sliceNode) Len() int {
+	return s.value.Len()
+}
+
+func (s sliceNode) Keys() []string {
+	return nil
+}
+
+func (s sliceNode) Value() any {
+	return s.value.Interface()
+}
+
+// structNode is a struct, its members are the fields of its encoding/json representation.
+type structNode struct {
+	value  reflect.Value
+	fields []structField
+}
+
+func (s structNode) Kind() Kind {
+	return Object
+}
+
+func (s structNode) Member(name string) (any, bool) {
+	for _, f := range s.fields {
+		if f.name == name {
+			v, ok := s.field(f)
+			if !ok {
+				return nil, false
+			}
+			return v.Interface(), true
+		}
+	}
+	return nil, false
+}
+
+func (s structNode) Index(int) (any, bool) {
+	return nil, false
+}
+
+func (s structNode) Len() int {
+	return len(s.Keys())
+}
+
+// Keys returns the member names in the order of the fields, like encoding/json.
+func (s structNode) Keys() []string {
+	keys := make([]string, 0, len(s.fields))
+	for _, f := range s.fields {
+		if _, ok := s.field(f); ok {
+			keys = append(keys, f.name)
+		}
+	}
+	return keys
+}
+
+func (s structNode) Value() any {
+	return s.value.Interface()
+}
+
+// field returns the value of the field, or false if it is omitted.
+func (s structNode) field(f structField) (reflect.Value, bool) {
+	v, ok := fieldByIndex(s.value, f.index)
+	if !ok || f.omitEmpty && isEmptyValue(v) || f.omitZero && v.IsZero() {
+		return reflect.Value{}, false
+	}
+	return v, true
+}
+
+// marshalNode returns the decoded JSON of a value with a custom JSON representation, or false if it can not be
+// marshaled.
+func marshalNode(m json.Marshaler) (any, bool) {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, false
+	}
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, false
+	}
+	return v, true
+}
+
+// mapKey returns the member name of a map key: a string, a formatted integer or the text of an
+// encoding.TextMarshaler.
+func mapKey(k reflect.Value) (string, bool) {
+	if k.Kind() == reflect.String {
+		return k.String(), true
+	}
+	if k.Type().Implements(textMarshalerType) {
+		if k.Kind() == reflect.Pointer && k.IsNil() {
+			return "", true
+		}
+		text, err := k.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err == nil
+	}
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10), true
+	default:
+		return "", false
+	}
+}
+
+// isMapKey reports whether map keys of the type can be represented as member names, see mapKey.
+func isMapKey(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	default:
+		return t.Implements(textMarshalerType)
+	}
+}
+
+// fieldByIndex returns the field with the given index path, or false if it is within a nil embedded pointer.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if 0 < i && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// isEmptyValue reports whether the value is omitted by the omitempty option of encoding/json.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Pointer:
+		return v.IsNil()
+	default:
+		return false
+	}
+}
+
+// structField is a member of the JSON representation of a struct.
+type structField struct {
+	name      string
+	index     []int
+	tagged    bool
+	omitEmpty bool
+	omitZero  bool
+}
+
+var structFieldCache sync.Map // map[reflect.Type][]structField
+
+// structFields returns the members of the JSON representation of the struct type, following the rules of
+// encoding/json: unexported and "-" fields are ignored, and the fields of embedded structs are promoted unless a field
+// with the same name is less nested, or tagged at the same depth. Fields of embedded pointers to structs are only
+// present if the pointer is not nil.
+func structFields(t reflect.Type) []structField {
+	if fields, ok := structFieldCache.Load(t); ok {
+		return fields.([]structField)
+	}
+	type embedded struct {
+		typ   reflect.Type
+		index []int
+	}
+	var fields []structField
+	depth := make(map[string]int)  // The depth at which a name was found first.
+	tagged := make(map[string]int) // The number of tagged fields with the name at that depth.
+	count := make(map[string]int)  // The number of fields with the name at that depth.
+	visited := make(map[reflect.Type]bool)
+	current := []embedded{{typ: t}}
+	for d := 0; len(current) != 0; d++ {
+		var next []embedded
+		for _, e := range current {
+			if visited[e.typ] {
+				continue
+			}
+			visited[e.typ] = true
+			for i := 0; i < e.typ.NumField(); i++ {
+				sf := e.typ.Field(i)
+				index := append(append(make([]int, 0, len(e.index)+1), e.index...), i)
+				tag := sf.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+				name, opts, _ := strings.Cut(tag, ",")
+				if !sf.IsExported() {
+					// Unlike encoding/json, the fields of unexported embedded structs are ignored, since their values
+					// can not be obtained through reflection.
+					continue
+				}
+				if ft := sf.Type; sf.Anonymous && name == "" {
+					if ft.Kind() == reflect.Pointer {
+						ft = ft.Elem()
+					}
+					if ft.Kind() == reflect.Struct {
+						next = append(next, embedded{typ: ft, index: index})
+						continue
+					}
+				}
+				f := structField{
+					name:      name,
+					index:     index,
+					tagged:    name != "",
+					omitEmpty: hasOption(opts, "omitempty"),
+					omitZero:  hasOption(opts, "omitzero"),
+				}
+				if f.name == "" {
+					f.name = sf.Name
+				}
+				if first, ok := depth[f.name]; ok && first < d {
+					continue
+				}
+				depth[f.name] = d
+				count[f.name]++
+				if f.tagged {
+					tagged[f.name]++
+				}
+				fields = append(fields, f)
+			}
+		}
+		current = next
+	}
+	// A name that occurs several times at the same depth is only kept if exactly one of these fields is tagged.
+	var dominant []structField
+	for _, f := range fields {
+		if count[f.name] == 1 || tagged[f.name] == 1 && f.tagged {
+			dominant = append(dominant, f)
+		}
+	}
+	// Like encoding/json, the fields are ordered by their position in the struct, embedded fields take the position of
+	// the embedded struct.
+	sort.Slice(dominant, func(i, j int) bool {
+		return slices.Compare(dominant[i].index, dominant[j].index) < 0
+	})
+	structFieldCache.Store(t, dominant)
+	return dominant
+}
+
+func hasOption(opts, option string) bool {
+	for opts != "" {
+		var opt string
+		opt, opts, _ = strings.Cut(opts, ",")
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
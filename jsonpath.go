@@ -1,9 +1,11 @@
 package jsonpath
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/0x51-dev/jsonpath/internal/grammar"
 	"github.com/0x51-dev/jsonpath/internal/ir"
+	"github.com/0x51-dev/jsonpath/internal/node"
 	"github.com/0x51-dev/upeg/parser/op"
 )
 
@@ -12,11 +14,65 @@ type NodeList []any
 
 // Path represents a JSONPath query.
 type Path struct {
-	query *ir.JSONPathQuery
+	query        *ir.JSONPathQuery
+	functions    map[string]*Function
+	embeddedJSON bool
+}
+
+// Style is the notation used to format a query.
+type Style int
+
+const (
+	// Canonical uses bracket notation for all segments and single quotes for all strings, e.g. $['a'][*]. The
+	// exponent of a number literal is written as e without a plus sign, but number literals are otherwise kept as
+	// written, e.g. 1.0 and 1.00 differ.
+	Canonical Style = iota
+	// Shorthand uses dot notation for member names and wildcards wherever it is allowed, e.g. $.a.*.
+	Shorthand
+)
+
+// Option configures the creation of a query.
+type Option func(*options)
+
+type options struct {
+	functions    []Function
+	embeddedJSON bool
+}
+
+// WithEmbeddedJSON makes the query descend into string values that hold a JSON object or array, as if the string was
+// replaced by the decoded value, e.g. $.body.status selects "ok" in {"body": "{\"status\": \"ok\"}"}. The strings
+// themselves are still selected as strings, and the normalized paths of their descendants continue the path of the
+// string.
+func WithEmbeddedJSON() Option {
+	return func(o *options) {
+		o.embeddedJSON = true
+	}
+}
+
+// WithFunctions restricts the function extensions that can be used by the query to the given functions, instead of
+// all registered functions.
+func WithFunctions(functions ...Function) Option {
+	return func(o *options) {
+		o.functions = append(make([]Function, 0, len(functions)), functions...)
+	}
 }
 
 // New creates a new JSONPath query from the given string.
-func New(query string) (*Path, error) {
+// Unless specified otherwise by the options, the query can use all registered function extensions.
+func New(query string, opts ...Option) (*Path, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	functions := registeredFunctions()
+	if o.functions != nil {
+		table, err := newFunctionTable(o.functions)
+		if err != nil {
+			return nil, err
+		}
+		functions = table
+	}
+
 	p, err := grammar.NewParser([]rune(query))
 	if err != nil {
 		return nil, err
@@ -29,52 +85,106 @@ func New(query string) (*Path, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &Path{query: q}, nil
+	if err := ir.Check(q, signatures(functions)); err != nil {
+		return nil, err
+	}
+	return &Path{query: q, functions: functions, embeddedJSON: o.embeddedJSON}, nil
 }
 
 // Apply applies the JSONPath query to the given argument.
 func (p Path) Apply(queryArgument any) NodeList {
-	return newContext(queryArgument).applyPath(p.query)
+	return nodes(newContext(queryArgument, p.functions, p.embeddedJSON).applyPath(p.query, nil))
+}
+
+// ApplyLocated applies the JSONPath query to the given argument, and returns every selected node together with its
+// normalized path.
+func (p Path) ApplyLocated(queryArgument any) LocatedNodeList {
+	result := newContext(queryArgument, p.functions, p.embeddedJSON).applyPath(p.query, &location{})
+	if result == nil {
+		return nil
+	}
+	nodeList := make(LocatedNodeList, len(result))
+	for i, n := range result {
+		nodeList[i] = LocatedNode{Location: n.location.path(), Node: n.node}
+	}
+	return nodeList
 }
 
-// Query returns the query string.
+// Format returns the query string in the given style. Parsing the result yields a query that is identical to p.
+func (p Path) Format(style Style) string {
+	switch style {
+	case Shorthand:
+		return ir.Format(p.query, ir.Shorthand)
+	default:
+		return ir.Format(p.query, ir.Canonical)
+	}
+}
+
+// Query returns the query string in canonical form.
 func (p Path) Query() string {
-	return p.query.String()
+	return p.Format(Canonical)
 }
 
 type context struct {
-	root any
+	root         any
+	functions    map[string]*Function
+	embeddedJSON bool
+	// decoded holds the strings decoded for embedded JSON, see node.
+	decoded map[string]node.Node
 }
 
-func newContext(root any) *context {
-	return &context{root: root}
+func newContext(root any, functions map[string]*Function, embeddedJSON bool) *context {
+	return &context{root: root, functions: functions, embeddedJSON: embeddedJSON}
 }
 
 // applyBracketedSelection returns a list of nodes from the given current node.
-func (ctx *context) applyBracketedSelection(segment *ir.BracketedSelection, node any, recursive bool) NodeList {
-	var nodeList NodeList
+func (ctx *context) applyBracketedSelection(segment *ir.BracketedSelection, n located) []located {
+	value, ok := ctx.node(n.node)
+	if !ok {
+		return nil
+	}
+	return ctx.applySelection(segment, n.location, value)
+}
+
+// applySelection returns the nodes that the selectors of the segment select from the given node, one selector after
+// another.
+func (ctx *context) applySelection(segment *ir.BracketedSelection, location *location, value node.Node) []located {
+	var nodeList []located
 	for _, selector := range segment.Selectors {
-		nodeList = append(
-			nodeList,
-			ctx.applySelector(selector, node, recursive)...,
-		)
+		nodeList = append(nodeList, ctx.applySelector(selector, location, value)...)
 	}
 	return nodeList
 }
 
-// applyPath returns a list of nodes from the given input, by applying the path segments.
-func (ctx *context) applyPath(p *ir.JSONPathQuery) NodeList {
-	nodeList := NodeList{ctx.root}
-	for _, segment := range p.Segments {
+// applyPath returns a list of nodes from the given input, by applying the path segments. The locations of the nodes
+// are only tracked if the root location is not nil.
+func (ctx *context) applyPath(p *ir.JSONPathQuery, root *location) []located {
+	return ctx.applySegments(p.Segments, located{node: ctx.root, location: root})
+}
+
+// applyRelQuery returns a list of nodes from the given current node, by applying the relative query segments.
+func (ctx *context) applyRelQuery(q *ir.RelQuery, node any) []located {
+	return ctx.applySegments(q.Segments, located{node: node})
+}
+
+// applySegments returns a list of nodes from the given node, by applying the segments one after another.
+func (ctx *context) applySegments(segments []ir.Segment, node located) []located {
+	nodeList := []located{node}
+	for _, segment := range segments {
 		if len(nodeList) == 0 {
 			return nil
 		}
 
 		switch segment := segment.(type) {
-		case ir.ChildSegment:
-			nodeList = ctx.applySegment(segment, nodeList, false)
+		case *ir.BracketedSelection:
+			nodeList = ctx.applySegment(segment, nodeList)
 		case *ir.DescendantSegment:
-			nodeList = ctx.applySegment(segment.Segment, nodeList, true)
+			// A descendant segment applies its selection to the input nodes and all their descendants.
+			var result []located
+			for _, node := range nodeList {
+				result = ctx.applyDescendantSegment(segment.Segment, node, result)
+			}
+			nodeList = result
 		default:
 			panic(fmt.Sprintf("unsupported segment type: %T", segment))
 		}
@@ -82,44 +192,69 @@ func (ctx *context) applyPath(p *ir.JSONPathQuery) NodeList {
 	return nodeList
 }
 
-// applySegment returns a list of nodes from the given input, by applying the segment.
-func (ctx *context) applySegment(segment ir.Segment, input NodeList, recursive bool) NodeList {
-	var nodeList NodeList
-	switch segment := segment.(type) {
-	case *ir.BracketedSelection:
-		for _, node := range input {
-			nodeList = append(
-				nodeList,
-				ctx.applyBracketedSelection(
-					segment,
-					node,
-					recursive,
-				)...,
-			)
-		}
-	case *ir.WildcardSelector:
-		for _, node := range input {
-			nodeList = append(
-				nodeList,
-				applyWildcardSelector(
-					node,
-					recursive,
-				)...,
-			)
-		}
-	case *ir.MemberNameShorthand:
-		for _, node := range input {
-			nodeList = append(
-				nodeList,
-				applyNameSelector(
-					&ir.NameSelector{Name: segment.Name},
-					node,
-					recursive,
-				)...,
-			)
+// applySegment returns a list of nodes from the given input, by applying the selection of the segment.
+func (ctx *context) applySegment(segment *ir.BracketedSelection, input []located) []located {
+	var nodeList []located
+	for _, node := range input {
+		nodeList = append(
+			nodeList,
+			ctx.applyBracketedSelection(
+				segment,
+				node,
+			)...,
+		)
+	}
+	return nodeList
+}
+
+// nodes returns the values of the given nodes.
+func nodes(l []located) NodeList {
+	if l == nil {
+		return nil
+	}
+	nodeList := make(NodeList, len(l))
+	for i, n := range l {
+		nodeList[i] = n.node
+	}
+	return nodeList
+}
+
+// node returns the node that selectors are applied to. If embedded JSON is enabled, a string that holds a JSON object
+// or array is replaced by the decoded value. The second return value is false if the value has no JSON
+// representation.
+func (ctx *context) node(v any) (node.Node, bool) {
+	n, ok := node.Of(v)
+	if !ok || !ctx.embeddedJSON || n.Kind() != node.String {
+		return n, ok
+	}
+	s, _ := n.Value().(string)
+	// Strings are decoded once per query application, e.g. a descendant segment and a filter may visit the same
+	// string many times. Strings that do not hold an object or an array are recorded as nil.
+	decoded, ok := ctx.decoded[s]
+	if !ok {
+		decoded = decodeJSON(s)
+		if ctx.decoded == nil {
+			ctx.decoded = make(map[string]node.Node)
 		}
+		ctx.decoded[s] = decoded
+	}
+	if decoded == nil {
+		return n, true
+	}
+	return decoded, true
+}
+
+// decodeJSON returns the node of the JSON object or array in the given string, or nil if it holds anything else.
+func decodeJSON(s string) node.Node {
+	var decoded any
+	if err := json.Unmarshal([]byte(s), &decoded); err != nil {
+		return nil
+	}
+	switch decoded.(type) {
+	case map[string]any, []any:
+		n, _ := node.Of(decoded)
+		return n
 	default:
-		panic(fmt.Sprintf("unsupported segment type: %T", segment))
+		return nil
 	}
-	return nodeList
 }
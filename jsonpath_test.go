@@ -150,6 +150,7 @@ func TestPath_Apply_descendantSegment(t *testing.T) {
 			query:   "$..[*]",
 			result: []any{
 				[]any{5, 3, []any{map[string]any{"j": 4}, map[string]any{"k": 6}}},
+				map[string]any{"j": 1, "k": 2},
 				5,
 				3,
 				[]any{map[string]any{"j": 4}, map[string]any{"k": 6}},
@@ -157,7 +158,6 @@ func TestPath_Apply_descendantSegment(t *testing.T) {
 				map[string]any{"k": 6},
 				4,
 				6,
-				map[string]any{"j": 1, "k": 2},
 				1,
 				2,
 			},
@@ -181,14 +181,168 @@ func TestPath_Apply_descendantSegment(t *testing.T) {
 			query:   "$.a..[0, 1]",
 			result: []any{
 				5,
-				map[string]any{"j": 4},
 				3,
+				map[string]any{"j": 4},
 				map[string]any{"k": 6},
 			},
 		},
 	}.Run(t, example)
 }
 
+func TestPath_ApplyLocated(t *testing.T) {
+	example := map[string]any{
+		"store": map[string]any{
+			"book": []any{
+				map[string]any{"author": "Nigel Rees"},
+				map[string]any{"author": "Evelyn Waugh"},
+			},
+		},
+		"a\n'": []any{1, 2},
+	}
+	for _, test := range []struct {
+		query     string
+		locations []string
+	}{
+		{
+			query:     "$.store.book[*].author",
+			locations: []string{"$['store']['book'][0]['author']", "$['store']['book'][1]['author']"},
+		},
+		{
+			query:     "$..author",
+			locations: []string{"$['store']['book'][0]['author']", "$['store']['book'][1]['author']"},
+		},
+		{
+			query:     "$.store.book[1:]",
+			locations: []string{"$['store']['book'][1]"},
+		},
+		{
+			query:     "$.store.book[?@.author == 'Nigel Rees']",
+			locations: []string{"$['store']['book'][0]"},
+		},
+		{
+			query:     `$["a\n'"][1]`,
+			locations: []string{`$['a\n\''][1]`},
+		},
+		{
+			query:     "$",
+			locations: []string{"$"},
+		},
+	} {
+		t.Run(test.query, func(t *testing.T) {
+			q, err := jsonpath.New(test.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var locations []string
+			for _, l := range q.ApplyLocated(example).Locations() {
+				locations = append(locations, l.String())
+			}
+			if !reflect.DeepEqual(locations, test.locations) {
+				t.Errorf("expected %v, got %v", test.locations, locations)
+			}
+		})
+	}
+}
+
+// https://www.rfc-editor.org/rfc/rfc9535.html#name-examples-10
+func TestNormalizedPath_String(t *testing.T) {
+	for _, test := range []struct {
+		path     jsonpath.NormalizedPath
+		expected string
+	}{
+		{jsonpath.NormalizedPath{"a"}, "$['a']"},
+		{jsonpath.NormalizedPath{1}, "$[1]"},
+		{jsonpath.NormalizedPath{"a", 2, "b"}, "$['a'][2]['b']"},
+		{jsonpath.NormalizedPath{"\u000B"}, `$['\u000b']`},
+		{jsonpath.NormalizedPath{"'\\\n"}, `$['\'\\\n']`},
+	} {
+		if s := test.path.String(); s != test.expected {
+			t.Errorf("expected %s, got %s", test.expected, s)
+		}
+	}
+}
+
+func TestPath_Format(t *testing.T) {
+	q, err := jsonpath.New(`$.store..book[?@.price < 10 && @["isbn"]].title`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := q.Format(jsonpath.Canonical); s != "$['store']..['book'][?@['price'] < 10 && @['isbn']]['title']" {
+		t.Errorf("unexpected canonical query: %s", s)
+	}
+	if s := q.Format(jsonpath.Shorthand); s != "$.store..book[?@.price < 10 && @.isbn].title" {
+		t.Errorf("unexpected shorthand query: %s", s)
+	}
+}
+
+func TestNew_withEmbeddedJSON(t *testing.T) {
+	example := map[string]any{
+		"events": []any{
+			map[string]any{"id": 1, "body": `{"status": "ok", "codes": [200]}`},
+			map[string]any{"id": 2, "body": `{"status": "failed"}`},
+			map[string]any{"id": 3, "body": `"ok"`},
+			map[string]any{"id": 4, "body": `{"status": `},
+		},
+	}
+	for _, test := range []struct {
+		query     string
+		result    jsonpath.NodeList
+		locations []string
+	}{
+		{
+			query:     "$.events[?@.body.status == 'ok'].id",
+			result:    jsonpath.NodeList{1},
+			locations: []string{"$['events'][0]['id']"},
+		},
+		{
+			query:     "$.events[0].body.codes[0]",
+			result:    jsonpath.NodeList{200.0},
+			locations: []string{"$['events'][0]['body']['codes'][0]"},
+		},
+		{
+			query:     "$..status",
+			result:    jsonpath.NodeList{"ok", "failed"},
+			locations: []string{"$['events'][0]['body']['status']", "$['events'][1]['body']['status']"},
+		},
+		{
+			query:     "$..[?@.status == $.events[0].body.status].codes[0]",
+			result:    jsonpath.NodeList{200.0},
+			locations: []string{"$['events'][0]['body']['codes'][0]"},
+		},
+		{
+			query:     "$.events[?@.body].body",
+			result:    jsonpath.NodeList{`{"status": "ok", "codes": [200]}`, `{"status": "failed"}`, `"ok"`, `{"status": `},
+			locations: []string{"$['events'][0]['body']", "$['events'][1]['body']", "$['events'][2]['body']", "$['events'][3]['body']"},
+		},
+	} {
+		q, err := jsonpath.New(test.query, jsonpath.WithEmbeddedJSON())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var result jsonpath.NodeList
+		var locations []string
+		for _, n := range q.ApplyLocated(example) {
+			result = append(result, n.Node)
+			locations = append(locations, n.Location.String())
+		}
+		if !reflect.DeepEqual(result, test.result) {
+			t.Errorf("%s: expected %v, got %v", test.query, test.result, result)
+		}
+		if !reflect.DeepEqual(locations, test.locations) {
+			t.Errorf("%s: expected %v, got %v", test.query, test.locations, locations)
+		}
+	}
+
+	// Without the option, strings have no children.
+	q, err := jsonpath.New("$..status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := q.Apply(example); len(r) != 0 {
+		t.Errorf("expected no embedded values, got %v", r)
+	}
+}
+
 type testCase struct {
 	comment string
 	query   string
@@ -0,0 +1,45 @@
+package jsonpath
+
+import (
+	"github.com/0x51-dev/jsonpath/internal/node"
+)
+
+// Node is a JSON value of a document. Queries can be applied to any value that implements Node, e.g. an adapter for
+// YAML nodes or an arena-backed document, without converting it to map[string]any and []any first. The children of a
+// node can be Nodes themselves, or any other value supported by NodeOf.
+//
+// Keys determines the document order of the members of an object: wildcards and descendant segments visit members in
+// the order that Keys returns, so an ordered document keeps its member order in the results. Go maps have no order,
+// their members are visited in ascending order of their names.
+type Node = node.Node
+
+// Kind is the JSON type of a node.
+type Kind = node.Kind
+
+const (
+	// NullKind is the kind of the JSON value null.
+	NullKind = node.Null
+	// BooleanKind is the kind of the JSON values true and false.
+	BooleanKind = node.Boolean
+	// NumberKind is the kind of JSON numbers.
+	NumberKind = node.Number
+	// StringKind is the kind of JSON strings.
+	StringKind = node.String
+	// ArrayKind is the kind of JSON arrays.
+	ArrayKind = node.Array
+	// ObjectKind is the kind of JSON objects.
+	ObjectKind = node.Object
+)
+
+// NodeOf returns the node that queries use to access the given value. Nodes are returned as they are, decoded JSON
+// and other Go values, like structs, typed maps and slices, are accessed like their encoding/json representation. The
+// second return value is false if the value has no JSON representation.
+func NodeOf(v any) (Node, bool) {
+	return node.Of(v)
+}
+
+// JSONValue returns the value in its JSON representation: nil, a bool, a number, a string, a []any or a
+// map[string]any. Nodes and other Go values are converted recursively.
+func JSONValue(v any) any {
+	return node.JSON(v)
+}
@@ -0,0 +1,197 @@
+package jsonpath_test
+
+import (
+	"github.com/0x51-dev/jsonpath"
+	"reflect"
+	"testing"
+)
+
+// element is a custom document type, similar to the nodes of a YAML or XML parser. Its members are kept in order.
+type element struct {
+	kind     jsonpath.Kind
+	scalar   any
+	names    []string
+	children []*element
+}
+
+func (e *element) Kind() jsonpath.Kind {
+	return e.kind
+}
+
+func (e *element) Member(name string) (any, bool) {
+	for i, n := range e.names {
+		if n == name {
+			return e.children[i], true
+		}
+	}
+	return nil, false
+}
+
+func (e *element) Index(i int) (any, bool) {
+	if e.kind != jsonpath.ArrayKind || i < 0 || len(e.children) <= i {
+		return nil, false
+	}
+	return e.children[i], true
+}
+
+func (e *element) Len() int {
+	return len(e.children)
+}
+
+func (e *element) Keys() []string {
+	return e.names
+}
+
+func (e *element) Value() any {
+	return e.scalar
+}
+
+func scalarElement(kind jsonpath.Kind, v any) *element {
+	return &element{kind: kind, scalar: v}
+}
+
+func TestPath_Apply_node(t *testing.T) {
+	first := &element{
+		kind:  jsonpath.ObjectKind,
+		names: []string{"title", "price", "tags"},
+		children: []*element{
+			scalarElement(jsonpath.StringKind, "Sayings"),
+			scalarElement(jsonpath.NumberKind, 8.95),
+			{kind: jsonpath.ArrayKind, children: []*element{scalarElement(jsonpath.StringKind, "classic")}},
+		},
+	}
+	second := &element{
+		kind:  jsonpath.ObjectKind,
+		names: []string{"title", "price", "tags", "isbn"},
+		children: []*element{
+			scalarElement(jsonpath.StringKind, "Moby Dick"),
+			scalarElement(jsonpath.NumberKind, uint16(22)),
+			{kind: jsonpath.ArrayKind},
+			scalarElement(jsonpath.NullKind, nil),
+		},
+	}
+	books := &element{kind: jsonpath.ArrayKind, children: []*element{first, second}}
+	example := &element{kind: jsonpath.ObjectKind, names: []string{"books"}, children: []*element{books}}
+
+	for _, test := range []struct {
+		query     string
+		result    jsonpath.NodeList
+		locations []string
+	}{
+		{
+			query:     "$.books[-1].title",
+			result:    jsonpath.NodeList{second.children[0]},
+			locations: []string{"$['books'][1]['title']"},
+		},
+		{
+			query:     "$.books[?@.price > 10].title",
+			result:    jsonpath.NodeList{second.children[0]},
+			locations: []string{"$['books'][1]['title']"},
+		},
+		{
+			query:     "$.books[?@.isbn == null]",
+			result:    jsonpath.NodeList{second},
+			locations: []string{"$['books'][1]"},
+		},
+		{
+			query:     "$.books[?length(@.tags) == 1 && @.tags[0] == 'classic'].price",
+			result:    jsonpath.NodeList{first.children[1]},
+			locations: []string{"$['books'][0]['price']"},
+		},
+		{
+			query:     "$.books[?@.tags == $.books[0].tags].title",
+			result:    jsonpath.NodeList{first.children[0]},
+			locations: []string{"$['books'][0]['title']"},
+		},
+		{
+			query:     "$.books[?count(@.*) == 4].title",
+			result:    jsonpath.NodeList{second.children[0]},
+			locations: []string{"$['books'][1]['title']"},
+		},
+		{
+			query:     "$.books[?match(@.title, 'S.*')].tags[:]",
+			result:    jsonpath.NodeList{first.children[2].children[0]},
+			locations: []string{"$['books'][0]['tags'][0]"},
+		},
+		{
+			query:     "$..tags..*",
+			result:    jsonpath.NodeList{first.children[2].children[0]},
+			locations: []string{"$['books'][0]['tags'][0]"},
+		},
+		{
+			query:  "$.books[0]..*",
+			result: jsonpath.NodeList{first.children[0], first.children[1], first.children[2], first.children[2].children[0]},
+			locations: []string{
+				"$['books'][0]['title']",
+				"$['books'][0]['price']",
+				"$['books'][0]['tags']",
+				"$['books'][0]['tags'][0]",
+			},
+		},
+		{
+			query:  "$.books[1].*",
+			result: jsonpath.NodeList{second.children[0], second.children[1], second.children[2], second.children[3]},
+			locations: []string{
+				"$['books'][1]['title']",
+				"$['books'][1]['price']",
+				"$['books'][1]['tags']",
+				"$['books'][1]['isbn']",
+			},
+		},
+	} {
+		q, err := jsonpath.New(test.query)
+		if err != nil {
+			t.Errorf("%s: %v", test.query, err)
+			continue
+		}
+		var result jsonpath.NodeList
+		var locations []string
+		for _, n := range q.ApplyLocated(example) {
+			result = append(result, n.Node)
+			locations = append(locations, n.Location.String())
+		}
+		if !reflect.DeepEqual(result, test.result) {
+			t.Errorf("%s: expected %v, got %v", test.query, test.result, result)
+		}
+		if !reflect.DeepEqual(locations, test.locations) {
+			t.Errorf("%s: expected %v, got %v", test.query, test.locations, locations)
+		}
+	}
+
+	expected := map[string]any{"title": "Moby Dick", "price": uint16(22), "tags": []any{}, "isbn": nil}
+	if v := jsonpath.JSONValue(second); !reflect.DeepEqual(v, expected) {
+		t.Errorf("expected %v, got %v", expected, v)
+	}
+}
+
+func TestNodeOf(t *testing.T) {
+	for _, test := range []struct {
+		value any
+		kind  jsonpath.Kind
+	}{
+		{value: nil, kind: jsonpath.NullKind},
+		{value: (*int)(nil), kind: jsonpath.NullKind},
+		{value: true, kind: jsonpath.BooleanKind},
+		{value: int8(1), kind: jsonpath.NumberKind},
+		{value: "a", kind: jsonpath.StringKind},
+		{value: []any{}, kind: jsonpath.ArrayKind},
+		{value: [2]string{}, kind: jsonpath.ArrayKind},
+		{value: map[string]any{}, kind: jsonpath.ObjectKind},
+		{value: struct{}{}, kind: jsonpath.ObjectKind},
+		{value: scalarElement(jsonpath.StringKind, "a"), kind: jsonpath.StringKind},
+	} {
+		n, ok := jsonpath.NodeOf(test.value)
+		if !ok {
+			t.Errorf("%#v: expected a node", test.value)
+			continue
+		}
+		if n.Kind() != test.kind {
+			t.Errorf("%#v: expected %s, got %s", test.value, test.kind, n.Kind())
+		}
+	}
+	for _, v := range []any{func() {}, make(chan int), map[bool]int{}} {
+		if n, ok := jsonpath.NodeOf(v); ok {
+			t.Errorf("%#v: expected no node, got %s", v, n.Kind())
+		}
+	}
+}
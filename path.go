@@ -0,0 +1,110 @@
+package jsonpath
+
+import (
+	"fmt"
+	"github.com/0x51-dev/jsonpath/internal/ir"
+	"strings"
+)
+
+// LocatedNode is a node together with its location in the query argument.
+type LocatedNode struct {
+	// Location is the normalized path of the node.
+	Location NormalizedPath
+	// Node is the value of the node.
+	Node any
+}
+
+// LocatedNodeList is a list of located nodes.
+type LocatedNodeList []LocatedNode
+
+// Nodes returns the values of the located nodes.
+func (l LocatedNodeList) Nodes() NodeList {
+	if l == nil {
+		return nil
+	}
+	nodeList := make(NodeList, len(l))
+	for i, n := range l {
+		nodeList[i] = n.Node
+	}
+	return nodeList
+}
+
+// Locations returns the normalized paths of the located nodes.
+func (l LocatedNodeList) Locations() []NormalizedPath {
+	if l == nil {
+		return nil
+	}
+	locations := make([]NormalizedPath, len(l))
+	for i, n := range l {
+		locations[i] = n.Location
+	}
+	return locations
+}
+
+// NormalizedPath is the location of a node within the query argument, as defined in RFC 9535 §2.7. Each element is
+// either a member name (string) or a non-negative array index (int).
+type NormalizedPath []any
+
+// String returns the normalized path, e.g. $['store']['book'][0].
+func (p NormalizedPath) String() string {
+	var str strings.Builder
+	str.WriteString("$")
+	for _, e := range p {
+		switch e := e.(type) {
+		case string:
+			str.WriteString(fmt.Sprintf("[%s]", ir.Quote(e)))
+		case int:
+			str.WriteString(fmt.Sprintf("[%d]", e))
+		default:
+			panic(fmt.Sprintf("unsupported path element type: %T", e))
+		}
+	}
+	return str.String()
+}
+
+// location is the location of a node during evaluation, linked to the location of its parent. Locations are only
+// tracked for ApplyLocated: the root location is then non-nil, and the normalized path is only built for the nodes
+// that end up in the result. Apply uses nil locations, so it never pays for them.
+type location struct {
+	parent  *location
+	element any
+}
+
+// member returns the location of the member with the given name. It returns nil if locations are not tracked.
+func (l *location) member(name string) *location {
+	if l == nil {
+		return nil
+	}
+	return &location{parent: l, element: name}
+}
+
+// index returns the location of the array element at the given index. It returns nil if locations are not tracked.
+func (l *location) index(i int) *location {
+	if l == nil {
+		return nil
+	}
+	return &location{parent: l, element: i}
+}
+
+// path returns the normalized path of the location.
+func (l *location) path() NormalizedPath {
+	var n int
+	for e := l; e != nil && e.parent != nil; e = e.parent {
+		n++
+	}
+	if n == 0 {
+		return nil
+	}
+	p := make(NormalizedPath, n)
+	for e := l; e.parent != nil; e = e.parent {
+		n--
+		p[n] = e.element
+	}
+	return p
+}
+
+// located is a node together with its location, which is nil if locations are not tracked.
+type located struct {
+	node     any
+	location *location
+}
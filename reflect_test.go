@@ -0,0 +1,223 @@
+package jsonpath_test
+
+import (
+	"github.com/0x51-dev/jsonpath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type status string
+
+type address struct {
+	City    string `json:"city"`
+	Country string `json:"country,omitempty"`
+}
+
+type Audit struct {
+	Created time.Time `json:"created"`
+	Version int       `json:"version"`
+}
+
+type Owner struct {
+	Name string `json:"name"`
+}
+
+type customer struct {
+	Audit
+	*Owner
+	ID       int              `json:"id"`
+	Name     string           `json:"name"`
+	Status   status           `json:"status"`
+	Address  *address         `json:"address,omitempty"`
+	Tags     []string         `json:"tags"`
+	Scores   map[string]uint8 `json:"scores"`
+	Ratings  map[int]float32  `json:"ratings,omitempty"`
+	Password string           `json:"-"`
+	Dash     bool             `json:"-,"`
+	Untagged string
+	internal string
+	Extra    map[string]string `json:"extra,omitempty"`
+}
+
+func TestPath_Apply_reflection(t *testing.T) {
+	created := time.Date(2024, 3, 2, 12, 0, 0, 0, time.UTC)
+	example := map[string]any{
+		"customers": []*customer{
+			{
+				Audit:    Audit{Created: created, Version: 2},
+				Owner:    &Owner{Name: "owner"},
+				ID:       1,
+				Name:     "Ada",
+				Status:   "active",
+				Address:  &address{City: "London"},
+				Tags:     []string{"vip", "early"},
+				Scores:   map[string]uint8{"b": 2, "a": 1},
+				Ratings:  map[int]float32{10: 4.5},
+				Password: "secret",
+				Dash:     true,
+				Untagged: "u",
+				internal: "i",
+			},
+			{
+				ID:     2,
+				Name:   "Bob",
+				Status: "inactive",
+				Tags:   []string{},
+			},
+			nil,
+		},
+	}
+	for _, test := range []struct {
+		query     string
+		result    jsonpath.NodeList
+		locations []string
+	}{
+		{
+			query:     "$.customers[?@.status == 'active'].name",
+			result:    jsonpath.NodeList{"Ada"},
+			locations: []string{"$['customers'][0]['name']"},
+		},
+		{
+			query:     "$.customers[0].address.city",
+			result:    jsonpath.NodeList{"London"},
+			locations: []string{"$['customers'][0]['address']['city']"},
+		},
+		{
+			query: "$.customers[0].address.country",
+		},
+		{
+			query: "$.customers[1].address",
+		},
+		{
+			query:     "$.customers[0].tags[-1]",
+			result:    jsonpath.NodeList{"early"},
+			locations: []string{"$['customers'][0]['tags'][1]"},
+		},
+		{
+			query:     "$.customers[0].scores.*",
+			result:    jsonpath.NodeList{uint8(1), uint8(2)},
+			locations: []string{"$['customers'][0]['scores']['a']", "$['customers'][0]['scores']['b']"},
+		},
+		{
+			query:     "$.customers[0].ratings['10']",
+			result:    jsonpath.NodeList{float32(4.5)},
+			locations: []string{"$['customers'][0]['ratings']['10']"},
+		},
+		{
+			query:     "$.customers[?@.version == 2 && @.created == '2024-03-02T12:00:00Z'].id",
+			result:    jsonpath.NodeList{1},
+			locations: []string{"$['customers'][0]['id']"},
+		},
+		{
+			query:     "$.customers[?length(@.tags) == 0].id",
+			result:    jsonpath.NodeList{2},
+			locations: []string{"$['customers'][1]['id']"},
+		},
+		{
+			query:     "$.customers[?@.scores.b > 1 && @['-'] == true].Untagged",
+			result:    jsonpath.NodeList{"u"},
+			locations: []string{"$['customers'][0]['Untagged']"},
+		},
+		{
+			query: "$.customers[*]['Password', 'password', 'internal', 'Audit', 'Owner', 'extra']",
+		},
+		{
+			query:     "$..name",
+			result:    jsonpath.NodeList{"Ada", "Bob"},
+			locations: []string{"$['customers'][0]['name']", "$['customers'][1]['name']"},
+		},
+		{
+			query:     "$.customers[2]",
+			result:    jsonpath.NodeList{(*customer)(nil)},
+			locations: []string{"$['customers'][2]"},
+		},
+		{
+			query:     "$.customers[?@ == null]",
+			result:    jsonpath.NodeList{(*customer)(nil)},
+			locations: []string{"$['customers'][2]"},
+		},
+	} {
+		q, err := jsonpath.New(test.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var result jsonpath.NodeList
+		var locations []string
+		for _, n := range q.ApplyLocated(example) {
+			result = append(result, n.Node)
+			locations = append(locations, n.Location.String())
+		}
+		if !reflect.DeepEqual(result, test.result) {
+			t.Errorf("%s: expected %v, got %v", test.query, test.result, result)
+		}
+		if !reflect.DeepEqual(locations, test.locations) {
+			t.Errorf("%s: expected %v, got %v", test.query, test.locations, locations)
+		}
+	}
+}
+
+// Fields of embedded structs are promoted, unless a field with the same name is less nested or tagged.
+func TestPath_Apply_reflectionEmbedded(t *testing.T) {
+	type Inner struct {
+		A int
+		B int `json:"b"`
+		C int
+	}
+	type Other struct {
+		B int
+		C int
+	}
+	type outer struct {
+		Inner
+		Other
+		A string
+	}
+	example := outer{Inner: Inner{A: 1, B: 2, C: 3}, Other: Other{B: 4, C: 5}, A: "a"}
+	for query, expected := range map[string]jsonpath.NodeList{
+		"$.A": {"a"},
+		"$.b": {2},
+		"$.B": {4},
+		"$.C": nil,
+		"$.*": {2, 4, "a"},
+	} {
+		q, err := jsonpath.New(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r := q.Apply(example); !reflect.DeepEqual(r, expected) {
+			t.Errorf("%s: expected %v, got %v", query, expected, r)
+		}
+	}
+}
+
+// Decoded JSON containers that hold other Go values are compared by their JSON representation.
+func TestPath_Apply_reflectionNested(t *testing.T) {
+	type inner struct {
+		S int `json:"s"`
+	}
+	type item struct {
+		ID  int `json:"id"`
+		Any any `json:"any"`
+	}
+	example := []item{
+		{ID: 1, Any: map[string]any{"s": inner{S: 3}}},
+		{ID: 2, Any: map[string]any{"s": map[string]any{"s": 3}}},
+		{ID: 3, Any: map[string]any{"s": inner{S: 4}}},
+		{ID: 4, Any: []any{inner{S: 3}}},
+		{ID: 5, Any: []any{map[string]any{"s": 3}}},
+	}
+	for query, expected := range map[string]jsonpath.NodeList{
+		"$[?@.any == $[0].any].id":     {1, 2},
+		"$[?@.any == $[3].any].id":     {4, 5},
+		"$[?@.any.s == $[1].any.s].id": {1, 2},
+	} {
+		q, err := jsonpath.New(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r := q.Apply(example); !reflect.DeepEqual(r, expected) {
+			t.Errorf("%s: expected %v, got %v", query, expected, r)
+		}
+	}
+}
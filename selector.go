@@ -3,213 +3,150 @@ package jsonpath
 import (
 	"fmt"
 	"github.com/0x51-dev/jsonpath/internal/ir"
-	"sort"
+	"github.com/0x51-dev/jsonpath/internal/node"
 )
 
 // applyIndexSelector returns a list of nodes from the given current node.
-// If the current node is a list, it returns the element at the index.
+// If the current node is an array, it returns the element at the index.
 // Otherwise, it returns nil.
-func applyIndexSelector(selector *ir.IndexSelector, node any, recursive bool) NodeList {
-	var nodeList NodeList
-	if node, ok := node.([]any); ok {
-		idx := selector.Index
-		if idx < 0 {
-			// A negative index-selector counts from the array end backwards, obtaining an equivalent non-negative
-			// index-selector by adding the length of the array to the negative index.
-			idx += len(node)
-		}
-		if len(node) <= idx {
-			// Nothing is selected, and it is not an error, if the index lies outside the range of the array.
-			return nil
-		}
-		nodeList = append(nodeList, node[idx])
-
-		if recursive {
-			for _, value := range node {
-				if value := applyIndexSelector(selector, value, recursive); value != nil {
-					nodeList = append(nodeList, value...)
-				}
-			}
-		}
+func applyIndexSelector(selector *ir.IndexSelector, location *location, value node.Node) []located {
+	if value.Kind() != node.Array {
+		return nil
 	}
-	if node, ok := node.(map[string]any); ok && recursive {
-		var keys []string
-		for key := range node {
-			keys = append(keys, key)
-		}
-		sort.Strings(keys)
-
-		for _, key := range keys {
-			if value := applyIndexSelector(selector, node[key], recursive); value != nil {
-				nodeList = append(nodeList, value...)
-			}
-		}
+	idx := selector.Index
+	if idx < 0 {
+		// A negative index-selector counts from the array end backwards, obtaining an equivalent non-negative
+		// index-selector by adding the length of the array to the negative index.
+		idx += int64(value.Len())
 	}
-	return nodeList
+	// Nothing is selected, and it is not an error, if the index lies outside the range of the array.
+	if idx < 0 || int64(value.Len()) <= idx {
+		return nil
+	}
+	v, ok := value.Index(int(idx))
+	if !ok {
+		return nil
+	}
+	return []located{{node: v, location: location.index(int(idx))}}
 }
 
 // applyNameSelector returns a value from the given current node.
-// If the current node is a map, it returns the value associated with the name.
+// If the current node is an object, it returns the value associated with the name.
 // Otherwise, it returns nil.
-func applyNameSelector(selector *ir.NameSelector, node any, recursive bool) NodeList {
-	var nodeList NodeList
-	if node, ok := node.(map[string]any); ok {
-		// Applying the name-selector to an object node selects a member value whose name equals the member name `M` or
-		// selects nothing if there is no such member value.
-		if value, ok := node[selector.Name]; ok {
-			nodeList = append(nodeList, value)
-		}
-		if recursive {
-			var keys []string
-			for key := range node {
-				keys = append(keys, key)
-			}
-			sort.Strings(keys)
-
-			for _, key := range keys {
-				if value := applyNameSelector(selector, node[key], recursive); value != nil {
-					nodeList = append(nodeList, value...)
-				}
-			}
-		}
-	}
-	if node, ok := node.([]any); ok && recursive {
-		for _, value := range node {
-			if value := applyNameSelector(selector, value, recursive); value != nil {
-				nodeList = append(nodeList, value...)
-			}
-		}
+func applyNameSelector(selector *ir.NameSelector, location *location, value node.Node) []located {
+	// Applying the name-selector to an object node selects a member value whose name equals the member name `M` or
+	// selects nothing if there is no such member value.
+	v, ok := value.Member(selector.Name)
+	if !ok {
+		return nil
 	}
-	return nodeList
+	return []located{{node: v, location: location.member(selector.Name)}}
 }
 
 // applySliceSelector returns a list of nodes from the given current node.
-// If the current node is a list, it returns a slice of elements.
+// If the current node is an array, it returns a slice of elements.
 // Otherwise, it returns nil.
-func applySliceSelector(selector *ir.SliceSelector, node any, recursive bool) NodeList {
-	var nodeList NodeList
-	if node, ok := node.([]any); ok {
-		if selector.Step == 0 {
-			// When step is 0, no elements are selected.
-			return nil
-		}
-
-		if 0 < selector.Step {
-			// When step is negative, elements are selected in reverse order. Thus, for example, 5:1:-2 selects elements
-			// with indices 5 and 3 (in that order), and ::-1 selects all the elements of an array in reverse order.
-			if selector.End == -1 {
-				selector.End = len(node)
-			}
-			for i := max(selector.Start, 0); i < min(selector.End, len(node)); i += selector.Step {
-				nodeList = append(nodeList, node[i])
-			}
-		} else {
-			// The array slice expression start:end:step selects elements at indices starting at start, incrementing by
-			// step, and ending with end (which is itself excluded).
-			if selector.Start == 0 && selector.End == -1 {
-				selector.Start = len(node) - 1
-			}
-			for i := min(selector.Start, len(node)); max(selector.End, -1) < i; i += selector.Step {
-				nodeList = append(nodeList, node[i])
-			}
-		}
-
-		if recursive {
-			for _, value := range node {
-				nodeList = append(
-					nodeList,
-					applySliceSelector(
-						selector,
-						value,
-						recursive,
-					)...,
-				)
-			}
-		}
+func applySliceSelector(selector *ir.SliceSelector, location *location, value node.Node) []located {
+	if value.Kind() != node.Array {
+		return nil
 	}
-	if node, ok := node.(map[string]any); ok && recursive {
-		var keys []string
-		for key := range node {
-			keys = append(keys, key)
-		}
-		sort.Strings(keys)
-
-		for _, key := range keys {
-			if value := applySliceSelector(selector, node[key], recursive); value != nil {
-				nodeList = append(nodeList, value...)
-			}
+	var nodeList []located
+	// The array slice expression start:end:step selects elements at indices starting at start, incrementing by step,
+	// and ending with end (which is itself excluded). When step is negative, elements are selected in reverse order.
+	// Thus, for example, 5:1:-2 selects elements with indices 5 and 3 (in that order), and ::-1 selects all the
+	// elements of an array in reverse order. When step is 0, no elements are selected.
+	lower, upper, step := selector.Bounds(value.Len())
+	if 0 < step {
+		for i := lower; i < upper; i += step {
+			v, _ := value.Index(int(i))
+			nodeList = append(nodeList, located{node: v, location: location.index(int(i))})
+		}
+	} else if step < 0 {
+		for i := upper; lower < i; i += step {
+			v, _ := value.Index(int(i))
+			nodeList = append(nodeList, located{node: v, location: location.index(int(i))})
 		}
 	}
 	return nodeList
 }
 
 // applyWildcardSelector returns a list of nodes from the given current node.
-// If the current node is a map, it returns a list of values sorted by keys.
-// If the current node is a list, it returns the list itself.
+// If the current node is an object, it returns its member values in the order of Keys.
+// If the current node is an array, it returns its elements.
 // Otherwise, it returns nil.
-func applyWildcardSelector(node any, recursive bool) NodeList {
-	var nodeList NodeList
-	switch node := node.(type) {
-	case map[string]any:
-		var keys []string
-		for key := range node {
-			keys = append(keys, key)
-		}
-		sort.Strings(keys)
-
-		for _, key := range keys {
-			value := node[key]
-			nodeList = append(nodeList, value)
-
-			if recursive {
-				nodeList = append(
-					nodeList,
-					applyWildcardSelector(
-						value,
-						recursive,
-					)...,
-				)
-			}
-		}
-		return nodeList
-	case []any:
-		nodeList = append(nodeList, node...)
-		if recursive {
-			for _, value := range node {
-				nodeList = append(
-					nodeList,
-					applyWildcardSelector(
-						value,
-						recursive,
-					)...,
-				)
-			}
-		}
-	}
+func applyWildcardSelector(location *location, value node.Node) []located {
+	var nodeList []located
+	forEachChild(value, func(c child) {
+		nodeList = append(nodeList, located{node: c.value, location: c.locate(location)})
+	})
 	return nodeList
 }
 
 // applySelector returns a list of nodes from the given current node.
 // A selector produces a node list consisting of zero or more children of the input value.
-func (ctx *context) applySelector(selector ir.Selector, node any, recursive bool) NodeList {
+func (ctx *context) applySelector(selector ir.Selector, location *location, value node.Node) []located {
 	switch selector := selector.(type) {
 	case *ir.NameSelector:
-		if v := applyNameSelector(selector, node, recursive); v != nil {
-			return v
-		}
-		return nil
+		return applyNameSelector(selector, location, value)
 	case *ir.WildcardSelector:
-		return applyWildcardSelector(node, recursive)
+		return applyWildcardSelector(location, value)
 	case *ir.SliceSelector:
-		return applySliceSelector(selector, node, recursive)
+		return applySliceSelector(selector, location, value)
 	case *ir.IndexSelector:
-		if v := applyIndexSelector(selector, node, recursive); v != nil {
-			return v
-		}
-		return nil
+		return applyIndexSelector(selector, location, value)
 	case *ir.FilterSelector:
-		return ctx.applyFilterSelector(selector, node, recursive)
+		return ctx.applyFilterSelector(selector, location, value)
 	default:
 		panic(fmt.Sprintf("unsupported selector type: %T", selector))
 	}
 }
+
+// child is a child of a node: either a member value together with its name, or an array element together with its
+// index.
+type child struct {
+	value  any
+	name   string
+	index  int
+	member bool
+}
+
+// locate returns the location of the child, given the location of its parent.
+func (c child) locate(parent *location) *location {
+	if c.member {
+		return parent.member(c.name)
+	}
+	return parent.index(c.index)
+}
+
+// forEachChild calls f for every child of the given node: the elements of an array in array order, or the member
+// values of an object in the order of Keys, which is document order. Any other node has no children.
+func forEachChild(value node.Node, f func(c child)) {
+	switch value.Kind() {
+	case node.Object:
+		for _, name := range value.Keys() {
+			v, _ := value.Member(name)
+			f(child{value: v, name: name, member: true})
+		}
+	case node.Array:
+		for i := 0; i < value.Len(); i++ {
+			v, _ := value.Index(i)
+			f(child{value: v, index: i})
+		}
+	}
+}
+
+// applyDescendantSegment appends the nodes that the selection of a descendant segment selects from the given node
+// and all its descendants to the node list. The descendants are visited in document order, i.e. every node is
+// visited before its children, and the children of a node in the order of forEachChild. No list of descendants is
+// built up front.
+func (ctx *context) applyDescendantSegment(segment *ir.BracketedSelection, n located, nodeList []located) []located {
+	value, ok := ctx.node(n.node)
+	if !ok {
+		return nodeList
+	}
+	nodeList = append(nodeList, ctx.applySelection(segment, n.location, value)...)
+	forEachChild(value, func(c child) {
+		nodeList = ctx.applyDescendantSegment(segment, located{node: c.value, location: c.locate(n.location)}, nodeList)
+	})
+	return nodeList
+}
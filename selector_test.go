@@ -1,6 +1,12 @@
 package jsonpath_test
 
-import "testing"
+import (
+	"github.com/0x51-dev/jsonpath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
 
 // https://www.rfc-editor.org/rfc/rfc9535.html#name-examples-5
 func TestPath_Apply_arraySliceSelector(t *testing.T) {
@@ -31,9 +37,101 @@ func TestPath_Apply_arraySliceSelector(t *testing.T) {
 			query:   "$[::-1]",
 			result:  []any{"g", "f", "e", "d", "c", "b", "a"},
 		},
+		{
+			comment: "Slice with negative start",
+			query:   "$[-2:]",
+			result:  []any{"f", "g"},
+		},
+		{
+			comment: "Slice with negative end",
+			query:   "$[:-5]",
+			result:  []any{"a", "b"},
+		},
+		{
+			comment: "Slice with literal -1 end",
+			query:   "$[0:-1]",
+			result:  []any{"a", "b", "c", "d", "e", "f"},
+		},
+		{
+			comment: "Slice with negative start, end and step",
+			query:   "$[-1:-3:-1]",
+			result:  []any{"g", "f"},
+		},
+		{
+			comment: "Slice with zero step",
+			query:   "$[::0]",
+			result:  nil,
+		},
+		{
+			comment: "Slice with out of range bounds",
+			query:   "$[-10:10:3]",
+			result:  []any{"a", "d", "g"},
+		},
+		{
+			comment: "Slice in reverse order with out of range bounds",
+			query:   "$[10:-10:-3]",
+			result:  []any{"g", "d", "a"},
+		},
 	}.Run(t, example)
 }
 
+// Applying a slice must not change the path, so it can be applied to arrays of different lengths, also concurrently.
+func TestPath_Apply_arraySliceSelector_reuse(t *testing.T) {
+	for _, query := range []string{"$[1:]", "$[::-1]", "$[:-1]"} {
+		q, err := jsonpath.New(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				long, short := q.Apply([]any{"a", "b", "c", "d"}), q.Apply([]any{"a", "b"})
+				if !reflect.DeepEqual(q.Apply([]any{"a", "b"}), short) || len(long) <= len(short) {
+					t.Errorf("%s: results depend on previous applications", query)
+				}
+			}()
+		}
+		wg.Wait()
+		if q.Query() != query {
+			t.Errorf("expected query %s, got %s", query, q.Query())
+		}
+	}
+}
+
+// https://www.rfc-editor.org/rfc/rfc9535.html#section-2.1-4.1
+func TestNew_integerRange(t *testing.T) {
+	for _, query := range []string{
+		"$[-9007199254740991]",
+		"$[9007199254740991]",
+		"$[-9007199254740991:9007199254740991:-9007199254740991]",
+	} {
+		if _, err := jsonpath.New(query); err != nil {
+			t.Errorf("%s: %v", query, err)
+		}
+	}
+	for _, test := range []struct {
+		query    string
+		selector string
+	}{
+		{query: "$[9007199254740992]", selector: `"9007199254740992"`},
+		{query: "$[-9007199254740992]", selector: `"-9007199254740992"`},
+		{query: "$[1:9007199254740992]", selector: `"1:9007199254740992"`},
+		{query: "$[::-9007199254740992]", selector: `"::-9007199254740992"`},
+		{query: "$[?@[9007199254740992] == 1]", selector: `"9007199254740992"`},
+	} {
+		_, err := jsonpath.New(test.query)
+		if err == nil {
+			t.Errorf("%s: expected an error", test.query)
+			continue
+		}
+		if !strings.Contains(err.Error(), test.selector) {
+			t.Errorf("%s: expected the error to name %s, got %v", test.query, test.selector, err)
+		}
+	}
+}
+
 // https://www.rfc-editor.org/rfc/rfc9535.html#name-examples-4
 func TestPath_Apply_indexSelector(t *testing.T) {
 	example := []any{"a", "b"}
@@ -81,6 +179,58 @@ func TestPath_Apply_nameSelector(t *testing.T) {
 	}.Run(t, example)
 }
 
+func TestPath_Apply_nameSelector_escapes(t *testing.T) {
+	example := map[string]any{
+		"é":          1,
+		"𝄞":          2,
+		"a\u0001'\\": 3,
+	}
+	testCases{
+		{
+			comment: "Escaped character",
+			query:   `$["\u00e9"]`,
+			result:  []any{1},
+		},
+		{
+			comment: "Escaped surrogate pair",
+			query:   `$['\uD834\uDD1E']`,
+			result:  []any{2},
+		},
+		{
+			comment: "Escaped control character, quote and backslash",
+			query:   `$['a\u0001\'\\']`,
+			result:  []any{3},
+		},
+	}.Run(t, example)
+}
+
+func TestPath_Query_nameSelector(t *testing.T) {
+	for _, test := range []struct {
+		query    string
+		expected string
+	}{
+		{query: `$["\u00e9"]`, expected: `$['é']`},
+		{query: `$["a\u0001\n'\\"]`, expected: `$['a\u0001\n\'\\']`},
+		{query: `$.a[?@["\t"] == "'"]`, expected: `$['a'][?@['\t'] == '\'']`},
+	} {
+		q, err := jsonpath.New(test.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if q.Query() != test.expected {
+			t.Errorf("expected query %s, got %s", test.expected, q.Query())
+		}
+		// The query string must be a valid query that is equivalent to the original one.
+		r, err := jsonpath.New(q.Query())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Query() != q.Query() {
+			t.Errorf("expected query %s, got %s", q.Query(), r.Query())
+		}
+	}
+}
+
 // https://www.rfc-editor.org/rfc/rfc9535.html#name-examples-3
 func TestPath_Apply_wildcardSelector(t *testing.T) {
 	example := map[string]any{